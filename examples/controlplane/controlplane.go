@@ -13,36 +13,37 @@
 package controlplane
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/metaform/dataplane-sdk-go/examples/common"
 	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk/client"
 )
 
 const (
-	startUrl            = "http://localhost:%d/dataflows/start"
-	terminateUrl        = "http://localhost:%d/dataflows/%s/terminate"
-	consumerPrepareURL  = "http://localhost:%d/dataflows/prepare"
+	dataPlaneURL        = "http://localhost:%d"
 	providerCallbackURL = "http://provider.com/dp/callback"
-	contentType         = "Content-Type"
-	jsonContentType     = "application/json"
 )
 
 // ControlPlaneSimulator simulates control plane interactions between a consumer and provider and drives their respective data planes.
 type ControlPlaneSimulator struct {
-	consumerDataPlane string
-	providerDataPlane string
+	consumerDataPlane *client.Client
+	providerDataPlane *client.Client
 }
 
 func NewSimulator() (*ControlPlaneSimulator, error) {
-	return &ControlPlaneSimulator{}, nil
+	consumer, err := client.NewClientBuilder(fmt.Sprintf(dataPlaneURL, common.ConsumerSignalingPort)).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer data plane client: %w", err)
+	}
+	provider, err := client.NewClientBuilder(fmt.Sprintf(dataPlaneURL, common.ProviderSignalingPort)).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider data plane client: %w", err)
+	}
+	return &ControlPlaneSimulator{consumerDataPlane: consumer, providerDataPlane: provider}, nil
 }
 
 func (c *ControlPlaneSimulator) ProviderStart(ctx context.Context,
@@ -67,40 +68,11 @@ func (c *ControlPlaneSimulator) ProviderStart(ctx context.Context,
 		SourceDataAddress: da,
 	}
 
-	serialized, err := json.Marshal(startMessage)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal start message: %w", err)
-	}
-
-	// Create the request
-	providerSignalingUrl := fmt.Sprintf(startUrl, common.ProviderSignalingPort)
-	req, err := http.NewRequestWithContext(ctx, "POST", providerSignalingUrl, bytes.NewBuffer(serialized))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set(contentType, jsonContentType)
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	resp, err := client.Do(req)
+	result, err := c.providerDataPlane.Start(ctx, startMessage)
 	if err != nil {
 		return nil, fmt.Errorf("start request failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	var message dsdk.DataFlowResponseMessage
-	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return message.DataAddress, nil
+	return result.Message.DataAddress, nil
 }
 
 func (c *ControlPlaneSimulator) ConsumerStart(ctx context.Context, processID string, source *dsdk.DataAddress) error {
@@ -119,39 +91,9 @@ func (c *ControlPlaneSimulator) ConsumerStart(ctx context.Context, processID str
 		SourceDataAddress: source,
 	}
 
-	serialized, err := json.Marshal(startMessage)
-	if err != nil {
-		return fmt.Errorf("failed to marshal start message: %w", err)
-	}
-
-	// Create the request
-	consumerSignalingUrl := fmt.Sprintf(startUrl, common.ConsumerSignalingPort)
-	req, err := http.NewRequestWithContext(ctx, "POST", consumerSignalingUrl, bytes.NewBuffer(serialized))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set(contentType, jsonContentType)
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
+	if _, err := c.consumerDataPlane.Start(ctx, startMessage); err != nil {
 		return fmt.Errorf("start request failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	var message dsdk.DataFlowResponseMessage
-	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
-	}
-
 	return nil
 }
 
@@ -171,72 +113,16 @@ func (c *ControlPlaneSimulator) ConsumerPrepare(ctx context.Context, processID s
 		},
 	}
 
-	serialized, err := json.Marshal(prepareMessage)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal prepare message: %w", err)
-	}
-
-	// Create the request
-	consumerSignalingUrl := fmt.Sprintf(consumerPrepareURL, common.ConsumerSignalingPort)
-	req, err := http.NewRequestWithContext(ctx, "POST", consumerSignalingUrl, bytes.NewBuffer(serialized))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set(contentType, jsonContentType)
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	resp, err := client.Do(req)
+	result, err := c.consumerDataPlane.Prepare(ctx, prepareMessage)
 	if err != nil {
 		return nil, fmt.Errorf("prepare request failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	var message dsdk.DataFlowResponseMessage
-	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return message.DataAddress, nil
+	return result.Message.DataAddress, nil
 }
 
 func (c *ControlPlaneSimulator) ProviderTerminate(ctx context.Context, processID string, agreementID string, datasetID string) error {
-	terminateMessage := dsdk.DataFlowTransitionMessage{Reason: "violation"}
-
-	serialized, err := json.Marshal(terminateMessage)
-	if err != nil {
-		return fmt.Errorf("failed to marshal terminate message: %w", err)
-	}
-
-	// Create the request
-	providerSignalingUrl := fmt.Sprintf(terminateUrl, common.ProviderSignalingPort, processID)
-	req, err := http.NewRequestWithContext(ctx, "POST", providerSignalingUrl, bytes.NewBuffer(serialized))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set(contentType, jsonContentType)
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	if err := c.providerDataPlane.Terminate(ctx, processID, "violation"); err != nil {
+		return fmt.Errorf("terminate request failed: %w", err)
 	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("start request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
 	return nil
 }
@@ -27,6 +27,7 @@ import (
 
 // ProviderDataPlane demonstrates how to use the Data Plane SDK. This implementation supports pull event streaming.
 type ProviderDataPlane struct {
+	sdk                   *dsdk.DataPlaneSDK
 	api                   *dsdk.DataPlaneApi
 	signalingServer       *http.Server
 	authService           *natsservices.AuthService
@@ -45,6 +46,8 @@ func NewDataPlane(authService *natsservices.AuthService,
 		TransactionContext(memory.InMemoryTrxContext{}).
 		OnPrepare(providerDataPlane.prepareProcessor).
 		OnStart(providerDataPlane.startProcessor).
+		OnResume(providerDataPlane.resumeProcessor).
+		OnRecover(providerDataPlane.recoverProcessor).
 		OnSuspend(providerDataPlane.suspendProcessor).
 		OnTerminate(providerDataPlane.terminateProcessor).
 		Build()
@@ -52,12 +55,18 @@ func NewDataPlane(authService *natsservices.AuthService,
 		return nil, err
 	}
 
+	providerDataPlane.sdk = sdk
 	providerDataPlane.api = dsdk.NewDataPlaneApi(sdk)
 
 	return providerDataPlane, nil
 }
 
 func (d *ProviderDataPlane) Init() {
+	// Resume flows that were in progress before a restart. With the in-memory store used here, there are none.
+	if err := d.sdk.Recover(context.Background()); err != nil {
+		log.Printf("[Provider Data Plane] Error recovering data flows: %v\n", err)
+	}
+
 	d.signalingServer = common.NewSignalingServer(d.api, common.ProviderSignalingPort)
 
 	// Start signaling server
@@ -118,6 +127,28 @@ func (d *ProviderDataPlane) startProcessor(_ context.Context,
 	return &dsdk.DataFlowResponseMessage{State: dsdk.Started, DataAddress: da}, nil
 }
 
+// resumeProcessor re-issues an access token for a suspended flow since the previous token was invalidated on suspension.
+func (d *ProviderDataPlane) resumeProcessor(ctx context.Context,
+	flow *dsdk.DataFlow,
+	sdk *dsdk.DataPlaneSDK,
+	options *dsdk.ProcessorOptions) (*dsdk.DataFlowResponseMessage, error) {
+	log.Printf("[Provider Data Plane] Resuming transfer for %s suspended with reason: %s\n", flow.CounterPartyID, options.SuspensionReason)
+	return d.startProcessor(ctx, flow, sdk, options)
+}
+
+// recoverProcessor restarts the publisher of a started flow after a restart. Access tokens are not persisted, so a new
+// token is issued.
+func (d *ProviderDataPlane) recoverProcessor(ctx context.Context,
+	flow *dsdk.DataFlow,
+	sdk *dsdk.DataPlaneSDK,
+	options *dsdk.ProcessorOptions) (*dsdk.DataFlowResponseMessage, error) {
+	if flow.State != dsdk.Started {
+		return &dsdk.DataFlowResponseMessage{State: flow.State}, nil
+	}
+	log.Printf("[Provider Data Plane] Recovering transfer for %s\n", flow.CounterPartyID)
+	return d.startProcessor(ctx, flow, sdk, options)
+}
+
 func (d *ProviderDataPlane) suspendProcessor(_ context.Context, flow *dsdk.DataFlow) error {
 	channel := flow.ID + "." + natsservices.ForwardSuffix
 	d.publisherService.Terminate(channel)
@@ -38,6 +38,7 @@ func NewDataPlane(publisherService *EventPublisherService) (*ProviderDataPlane,
 		TransactionContext(memory.InMemoryTrxContext{}).
 		OnPrepare(dataplane.prepareProcessor).
 		OnStart(dataplane.startProcessor).
+		OnResume(dataplane.resumeProcessor).
 		OnTerminate(dataplane.terminateProcessor).
 		OnSuspend(dataplane.noopHandler).
 		Build()
@@ -98,6 +99,15 @@ func (d *ProviderDataPlane) startProcessor(ctx context.Context,
 	return &dsdk.DataFlowResponseMessage{State: dsdk.Started}, nil
 }
 
+// resumeProcessor restarts publishing using the new destination token issued by the consumer for a suspended flow.
+func (d *ProviderDataPlane) resumeProcessor(ctx context.Context,
+	flow *dsdk.DataFlow,
+	sdk *dsdk.DataPlaneSDK,
+	options *dsdk.ProcessorOptions) (*dsdk.DataFlowResponseMessage, error) {
+	log.Printf("[Provider Data Plane] Resuming transfer for %s suspended with reason: %s\n", flow.CounterPartyID, options.SuspensionReason)
+	return d.startProcessor(ctx, flow, sdk, options)
+}
+
 func (d *ProviderDataPlane) terminateProcessor(_ context.Context, flow *dsdk.DataFlow) error {
 	d.publisherService.Terminate(flow.ID)
 
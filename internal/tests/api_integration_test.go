@@ -14,7 +14,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
@@ -28,31 +27,10 @@ var ctx = context.Background()
 // database SQL database connection, used for test setup and assertionss
 var database *sql.DB
 
-// newServerWithSdk instantiates a new HTTP server using the DataPlane SDK and registers its callbacks with endpoints
+// newServerWithSdk instantiates a new HTTP handler using the DataPlane SDK and registers its callbacks with endpoints
 func newServerWithSdk(t *testing.T, sdk *dsdk.DataPlaneSDK) http.Handler {
 	t.Helper()
-	sdkApi := dsdk.NewDataPlaneApi(sdk)
-	r := chi.NewRouter()
-
-	r.Post("/dataflows/start", sdkApi.Start)
-	r.Post("/dataflows/{id}/start", func(writer http.ResponseWriter, request *http.Request) {
-		id := chi.URLParam(request, "id")
-		sdkApi.StartById(writer, request, id)
-	})
-	r.Post("/dataflows/prepare", sdkApi.Prepare)
-	r.Post("/dataflows/{id}/terminate", func(writer http.ResponseWriter, request *http.Request) {
-		id := chi.URLParam(request, "id")
-		sdkApi.Terminate(id, writer, request)
-	})
-	r.Post("/dataflows/{id}/suspend", func(writer http.ResponseWriter, request *http.Request) {
-		id := chi.URLParam(request, "id")
-		sdkApi.Suspend(id, writer, request)
-	})
-	r.Get("/dataflows/{id}/status", func(writer http.ResponseWriter, request *http.Request) {
-		id := chi.URLParam(request, "id")
-		sdkApi.Status(id, writer, request)
-	})
-	return r
+	return dsdk.NewSignalingHandler(dsdk.NewDataPlaneApi(sdk), dsdk.SignalingHandlerOptions{})
 }
 
 var handler http.Handler
@@ -286,6 +264,11 @@ func Test_Suspend_WithReason(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, dsdk.Suspended, byId.State)
 	assert.Equal(t, "test reason", byId.ErrorDetail)
+	if assert.NotNil(t, byId.Failure) {
+		assert.Equal(t, dsdk.FailureCodeSuspended, byId.Failure.Code)
+		assert.Equal(t, dsdk.FailureOriginControlPlane, byId.Failure.Origin)
+		assert.True(t, byId.Failure.Retryable)
+	}
 }
 
 func Test_Suspend_WhenNotExists(t *testing.T) {
@@ -365,6 +348,47 @@ func Test_Terminate_WhenNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, rr.Code)
 }
 
+func Test_Complete_Success(t *testing.T) {
+	id := uuid.New().String()
+	flow, err := newFlowBuilder().ID(id).State(dsdk.Started).Build()
+	assert.NoError(t, err)
+	store := postgres.NewStore(database)
+	err = store.Create(ctx, flow)
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/dataflows/"+flow.ID+"/completed", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	byId, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, dsdk.Completed, byId.State)
+}
+
+func Test_Complete_WhenNotStarted(t *testing.T) {
+	id := uuid.New().String()
+	flow, err := newFlowBuilder().ID(id).State(dsdk.Prepared).Build()
+	assert.NoError(t, err)
+	err = postgres.NewStore(database).Create(ctx, flow)
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/dataflows/"+flow.ID+"/completed", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func Test_Complete_WhenNotFound(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "/dataflows/"+uuid.New().String()+"/completed", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
 func Test_GetStatus(t *testing.T) {
 	id := uuid.New().String()
 	flow, err := newFlowBuilder().ID(id).State(dsdk.Started).Build()
@@ -14,18 +14,20 @@ package dsdk
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
-
-	"github.com/google/uuid"
+	"strings"
 )
 
 const contentType = "Content-Type"
 const jsonContentType = "application/json"
 
+// DataPlaneApi implements the Data Plane Signaling endpoints as HTTP handlers. NewSignalingHandler exposes them on a
+// router. Applications that mount the handlers on their own mux instead get the same responses: each handler rejects
+// unsupported methods with the 405 problem the router returns, see NewSignalingHandler.
 type DataPlaneApi struct {
 	sdk *DataPlaneSDK
 }
@@ -35,24 +37,28 @@ func NewDataPlaneApi(sdk *DataPlaneSDK) *DataPlaneApi {
 }
 
 func (d *DataPlaneApi) Prepare(w http.ResponseWriter, r *http.Request) {
+	ctx := contextWithFlow(r.Context(), string(OperationPrepare), "", "", "")
 	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusBadRequest)
+		d.methodNotAllowed(ctx, w, http.MethodPost)
 		return
 	}
 	var prepareMessage DataFlowPrepareMessage
 
 	if err := json.NewDecoder(r.Body).Decode(&prepareMessage); err != nil {
-		d.decodingError(w, err)
+		d.decodingError(ctx, w, err)
 		return
 	}
+	ctx = ContextWithMessageID(contextWithFlow(ctx, string(OperationPrepare), prepareMessage.ProcessID,
+		prepareMessage.ParticipantID, prepareMessage.CounterPartyID), prepareMessage.MessageID)
 
 	if err := prepareMessage.Validate(); err != nil {
-		d.handleError(err, w)
+		d.handleError(ctx, err, w)
+		return
 	}
 
-	response, err := d.sdk.Prepare(r.Context(), prepareMessage)
+	response, err := d.sdk.Prepare(ctx, prepareMessage)
 	if err != nil {
-		d.handleError(err, w)
+		d.handleError(ctx, err, w)
 		return
 	}
 
@@ -62,29 +68,32 @@ func (d *DataPlaneApi) Prepare(w http.ResponseWriter, r *http.Request) {
 	} else {
 		code = http.StatusAccepted
 	}
-	d.writeResponse(w, code, response)
+	d.writeResponse(ctx, w, code, response)
 }
 
 func (d *DataPlaneApi) Start(w http.ResponseWriter, r *http.Request) {
+	ctx := contextWithFlow(r.Context(), string(OperationStart), "", "", "")
 	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusBadRequest)
+		d.methodNotAllowed(ctx, w, http.MethodPost)
 		return
 	}
 	var startMessage DataFlowStartMessage
 
 	if err := json.NewDecoder(r.Body).Decode(&startMessage); err != nil {
-		d.decodingError(w, err)
+		d.decodingError(ctx, w, err)
 		return
 	}
+	ctx = ContextWithMessageID(contextWithFlow(ctx, string(OperationStart), startMessage.ProcessID,
+		startMessage.ParticipantID, startMessage.CounterPartyID), startMessage.MessageID)
 
 	if err := startMessage.Validate(); err != nil {
-		d.handleError(err, w)
+		d.handleError(ctx, err, w)
 		return
 	}
 
-	response, err := d.sdk.Start(r.Context(), startMessage)
+	response, err := d.sdk.Start(ctx, startMessage)
 	if err != nil {
-		d.handleError(err, w)
+		d.handleError(ctx, err, w)
 		return
 	}
 
@@ -93,32 +102,34 @@ func (d *DataPlaneApi) Start(w http.ResponseWriter, r *http.Request) {
 		code = http.StatusOK
 	} else {
 		code = http.StatusAccepted
-		w.Header().Set("Location", "/dataflows/"+startMessage.ProcessID)
+		w.Header().Set("Location", flowLocation(r, startMessage.ProcessID))
 	}
-	d.writeResponse(w, code, response)
+	d.writeResponse(ctx, w, code, response)
 
 }
 
 func (d *DataPlaneApi) StartById(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := contextWithFlow(r.Context(), string(OperationStart), id, "", "")
 	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusBadRequest)
+		d.methodNotAllowed(ctx, w, http.MethodPost)
 		return
 	}
 	var startMessage DataFlowStartByIdMessage
 
 	if err := json.NewDecoder(r.Body).Decode(&startMessage); err != nil {
-		d.decodingError(w, err)
+		d.decodingError(ctx, w, err)
 		return
 	}
+	ctx = ContextWithMessageID(ctx, startMessage.MessageID)
 
 	if err := startMessage.Validate(); err != nil {
-		d.handleError(err, w)
+		d.handleError(ctx, err, w)
 		return
 	}
 
-	response, err := d.sdk.StartById(r.Context(), id, startMessage)
+	response, err := d.sdk.StartById(ctx, id, startMessage)
 	if err != nil {
-		d.handleError(err, w)
+		d.handleError(ctx, err, w)
 		return
 	}
 
@@ -127,37 +138,40 @@ func (d *DataPlaneApi) StartById(w http.ResponseWriter, r *http.Request, id stri
 		code = http.StatusOK
 	} else {
 		code = http.StatusAccepted
-		w.Header().Set("Location", "/dataflows/"+id)
+		w.Header().Set("Location", flowLocation(r, id))
 	}
-	d.writeResponse(w, code, response)
+	d.writeResponse(ctx, w, code, response)
 }
 
-func (d *DataPlaneApi) Terminate(id string, w http.ResponseWriter, r *http.Request) {
-	reason := ""
+func (d *DataPlaneApi) Terminate(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := contextWithFlow(r.Context(), string(OperationTerminate), id, "", "")
+	if r.Method != http.MethodPost {
+		d.methodNotAllowed(ctx, w, http.MethodPost)
+		return
+	}
+	var terminateMessage DataFlowTransitionMessage
 	// Peek into the body
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
-		d.decodingError(w, err)
+		d.decodingError(ctx, w, err)
 		return
 	}
-	// if a body was sent, parse it, read the reason
+	// if a body was sent, parse it, read the reason and failure
 
 	if len(bodyBytes) > 0 {
-		var terminateMessage DataFlowTransitionMessage
-
 		if err := json.NewDecoder(bytes.NewReader(bodyBytes)).Decode(&terminateMessage); err != nil {
-			d.decodingError(w, err)
+			d.decodingError(ctx, w, err)
 			return
 		}
 		if err := terminateMessage.Validate(); err != nil {
-			d.handleError(err, w)
+			d.handleError(ctx, err, w)
 			return
 		}
-		reason = terminateMessage.Reason
+		ctx = ContextWithMessageID(ctx, terminateMessage.MessageID)
 	}
-	terminateError := d.sdk.Terminate(r.Context(), id, reason)
+	terminateError := d.sdk.TerminateWithFailure(ctx, id, terminateMessage.failure(FailureCodeTerminated, false))
 	if terminateError != nil {
-		d.handleError(terminateError, w)
+		d.handleError(ctx, terminateError, w)
 		return
 	}
 
@@ -165,33 +179,35 @@ func (d *DataPlaneApi) Terminate(id string, w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusOK)
 }
 
-func (d *DataPlaneApi) Suspend(id string, w http.ResponseWriter, r *http.Request) {
-
-	reason := ""
+func (d *DataPlaneApi) Suspend(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := contextWithFlow(r.Context(), string(OperationSuspend), id, "", "")
+	if r.Method != http.MethodPost {
+		d.methodNotAllowed(ctx, w, http.MethodPost)
+		return
+	}
+	var suspendMessage DataFlowTransitionMessage
 	// Peek into the body
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
-		d.decodingError(w, err)
+		d.decodingError(ctx, w, err)
 		return
 	}
-	// if a body was sent, parse it, read the reason
+	// if a body was sent, parse it, read the reason and failure
 	if len(bodyBytes) > 0 {
-		var suspendMessage DataFlowTransitionMessage
-
 		if err := json.NewDecoder(bytes.NewReader(bodyBytes)).Decode(&suspendMessage); err != nil {
-			d.decodingError(w, err)
+			d.decodingError(ctx, w, err)
 			return
 		}
 		if err := suspendMessage.Validate(); err != nil {
-			d.handleError(err, w)
+			d.handleError(ctx, err, w)
 			return
 		}
-		reason = suspendMessage.Reason
+		ctx = ContextWithMessageID(ctx, suspendMessage.MessageID)
 	}
 
-	suspensionError := d.sdk.Suspend(r.Context(), id, reason)
+	suspensionError := d.sdk.SuspendWithFailure(ctx, id, suspendMessage.failure(FailureCodeSuspended, true))
 	if suspensionError != nil {
-		d.handleError(suspensionError, w)
+		d.handleError(ctx, suspensionError, w)
 		return
 	}
 
@@ -200,14 +216,51 @@ func (d *DataPlaneApi) Suspend(id string, w http.ResponseWriter, r *http.Request
 
 }
 
-func (d *DataPlaneApi) Status(processID string, w http.ResponseWriter, r *http.Request) {
+func (d *DataPlaneApi) Complete(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := contextWithFlow(r.Context(), string(OperationComplete), id, "", "")
+	if r.Method != http.MethodPost {
+		d.methodNotAllowed(ctx, w, http.MethodPost)
+		return
+	}
+	var completionMessage DataFlowCompletionMessage
+	// Peek into the body
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		d.decodingError(ctx, w, err)
+		return
+	}
+	// if a body was sent, parse it and read the message ID
+	if len(bodyBytes) > 0 {
+		if err := json.NewDecoder(bytes.NewReader(bodyBytes)).Decode(&completionMessage); err != nil {
+			d.decodingError(ctx, w, err)
+			return
+		}
+		if err := completionMessage.Validate(); err != nil {
+			d.handleError(ctx, err, w)
+			return
+		}
+		ctx = ContextWithMessageID(ctx, completionMessage.MessageID)
+	}
+
+	completionError := d.sdk.Complete(ctx, id)
+	if completionError != nil {
+		d.handleError(ctx, completionError, w)
+		return
+	}
+
+	w.Header().Set(contentType, jsonContentType)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (d *DataPlaneApi) Status(w http.ResponseWriter, r *http.Request, processID string) {
+	ctx := contextWithFlow(r.Context(), "status", processID, "", "")
 	if r.Method != http.MethodGet {
-		http.Error(w, "Invalid request method", http.StatusBadRequest)
+		d.methodNotAllowed(ctx, w, http.MethodGet)
 		return
 	}
-	dataFlow, err := d.sdk.Status(r.Context(), processID)
+	dataFlow, err := d.sdk.Status(ctx, processID)
 	if err != nil {
-		d.handleError(err, w)
+		d.handleError(ctx, err, w)
 		return
 	}
 	w.Header().Set(contentType, jsonContentType)
@@ -215,45 +268,87 @@ func (d *DataPlaneApi) Status(processID string, w http.ResponseWriter, r *http.R
 		State:      dataFlow.State,
 		DataFlowID: dataFlow.ID,
 	}
-	d.writeResponse(w, http.StatusOK, response)
+	if !dataFlow.SourceDataAddress.IsEmpty() {
+		response.SourceDataAddress = &dataFlow.SourceDataAddress
+	}
+	if !dataFlow.DestinationDataAddress.IsEmpty() {
+		response.DestinationDataAddress = &dataFlow.DestinationDataAddress
+	}
+	response.Failure = dataFlow.Failure
+	d.writeResponse(ctx, w, http.StatusOK, response)
+}
+
+// History returns the state transitions of a data flow.
+func (d *DataPlaneApi) History(w http.ResponseWriter, r *http.Request, processID string) {
+	ctx := contextWithFlow(r.Context(), "history", processID, "", "")
+	if r.Method != http.MethodGet {
+		d.methodNotAllowed(ctx, w, http.MethodGet)
+		return
+	}
+	transitions, err := d.sdk.History(ctx, processID)
+	if err != nil {
+		d.handleError(ctx, err, w)
+		return
+	}
+	if transitions == nil {
+		transitions = []StateTransition{}
+	}
+	d.writeResponse(ctx, w, http.StatusOK, DataFlowHistoryResponseMessage{DataFlowID: processID, Transitions: transitions})
 }
 
-func (d *DataPlaneApi) decodingError(w http.ResponseWriter, err error) {
-	id := uuid.NewString()
-	d.sdk.Monitor.Printf("Error decoding flow [%s]: %v\n", id, err)
-	d.writeResponse(w, http.StatusBadRequest, &DataFlowResponseMessage{Error: fmt.Sprintf("Failed to decode request body [%s]", id)})
+// methodNotAllowed writes a 405 problem with an Allow header listing the allowed methods. It is the only 405 response
+// of the API: the method checks of the handlers use it when they are mounted without NewSignalingHandler, whose router
+// rejects unsupported methods with it before the handlers are reached.
+func (d *DataPlaneApi) methodNotAllowed(ctx context.Context, w http.ResponseWriter, allowed ...string) {
+	for _, method := range allowed {
+		w.Header().Add("Allow", method)
+	}
+	d.writeProblem(ctx, w, &Problem{Type: ProblemTypeMethodNotAllowed, Title: "Method not allowed",
+		Status: http.StatusMethodNotAllowed, Detail: fmt.Sprintf("The endpoint supports %s requests", strings.Join(allowed, ", "))})
 }
 
-// handleError writes an error message to the HTTP response that indicates "any other" error, such as 409, 500, etc.
-func (d *DataPlaneApi) handleError(err error, w http.ResponseWriter) {
-
-	switch {
-	case errors.Is(err, ErrValidation), errors.Is(err, ErrInvalidTransition):
-		d.badRequest(err.Error(), w)
-	case errors.Is(err, ErrNotFound):
-		d.writeResponse(w, http.StatusNotFound, &DataFlowResponseMessage{Error: err.Error()})
-	case errors.Is(err, ErrConflict):
-		message := fmt.Sprintf("%s", err)
-		d.writeResponse(w, http.StatusConflict, &DataFlowResponseMessage{Error: message})
-	default:
-		message := fmt.Sprintf("Error processing flow: %s", err)
-		d.sdk.Monitor.Println(message)
-		d.writeResponse(w, http.StatusInternalServerError, &DataFlowResponseMessage{Error: message})
+// flowLocation returns the resource path of a data flow relative to the dataflows path of the current request, so that
+// base paths and version prefixes are preserved.
+func flowLocation(r *http.Request, id string) string {
+	const dataflowsPath = "/dataflows/"
+	prefix := ""
+	if i := strings.LastIndex(r.URL.Path, dataflowsPath); i >= 0 {
+		prefix = r.URL.Path[:i]
 	}
+	return prefix + dataflowsPath + id
 }
 
-func (d *DataPlaneApi) badRequest(errMsg string, w http.ResponseWriter) {
-	d.writeResponse(w, http.StatusBadRequest, &DataFlowResponseMessage{Error: errMsg})
+func (d *DataPlaneApi) decodingError(ctx context.Context, w http.ResponseWriter, err error) {
+	ctx, _ = withCorrelationID(ctx, w)
+	d.sdk.logger().InfoContext(ctx, "Error decoding request body", LogKeyError, err)
+	d.writeProblem(ctx, w, &Problem{Type: ProblemTypeValidation, Title: "Validation failed", Status: http.StatusBadRequest,
+		Detail: "The request body is not a valid signaling message"})
 }
 
-func (d *DataPlaneApi) writeResponse(w http.ResponseWriter, code int, response any) {
+// handleError writes the problem details of an error to the HTTP response. Internal errors are logged but not disclosed
+// to the caller, who receives the correlation ID of the log record instead.
+func (d *DataPlaneApi) handleError(ctx context.Context, err error, w http.ResponseWriter) {
+	ctx, _ = withCorrelationID(ctx, w)
+	problem := problemFor(err)
+	if problem.Status >= http.StatusInternalServerError {
+		d.sdk.logger().ErrorContext(ctx, "Error processing signaling request", LogKeyError, err)
+	} else {
+		d.sdk.logger().InfoContext(ctx, "Rejected signaling request", "status", problem.Status, LogKeyError, err)
+	}
+	d.writeProblem(ctx, w, problem)
+}
+
+// writeProblem completes the problem with the correlation ID of the request and writes it to the HTTP response.
+func (d *DataPlaneApi) writeProblem(ctx context.Context, w http.ResponseWriter, problem *Problem) {
+	_, problem.CorrelationID = withCorrelationID(ctx, w)
+	writeProblem(w, problem)
+}
+
+func (d *DataPlaneApi) writeResponse(ctx context.Context, w http.ResponseWriter, code int, response any) {
 	w.Header().Set(contentType, jsonContentType)
 	w.WriteHeader(code)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		id := uuid.NewString()
-		message := fmt.Sprintf("Error encoding response [%s]", id)
-		d.sdk.Monitor.Println(message)
-		d.writeResponse(w, http.StatusInternalServerError, &DataFlowResponseMessage{Error: message})
-		return
+		// the status has been sent already
+		d.sdk.logger().ErrorContext(ctx, "Error encoding response", LogKeyError, err)
 	}
 }
@@ -0,0 +1,57 @@
+package dsdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_DataPlaneApi_Terminate_ConcurrentModification(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	api := NewDataPlaneApi(&DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onTerminate: func(ctx context.Context, flow *DataFlow) error {
+			return nil
+		},
+	})
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Started,
+	}, nil)
+	store.EXPECT().Save(mock.Anything, mock.AnythingOfType("*dsdk.DataFlow")).
+		Return(fmt.Errorf("%w: data flow flow123 was modified concurrently", ErrConflict))
+
+	req := httptest.NewRequest(http.MethodPost, "/dataflows/flow123/terminate", nil)
+	rr := httptest.NewRecorder()
+	api.Terminate(rr, req, "flow123")
+
+	problem := decodeProblem(t, rr, http.StatusConflict)
+	assert.Equal(t, ProblemTypeConflict, problem.Type)
+	assert.NotContains(t, problem.Detail, "flow123")
+}
+
+func Test_DataPlaneApi_MethodNotAllowed_WithoutRouter(t *testing.T) {
+	api := NewDataPlaneApi(&DataPlaneSDK{Store: NewMockDataplaneStore(t), TrxContext: &mockTrxContext{}})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dataflows/start", api.Start)
+	router := NewSignalingHandler(api, SignalingHandlerOptions{})
+
+	for name, handler := range map[string]http.Handler{"mux": mux, "router": router} {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/start", nil))
+
+			problem := decodeProblem(t, rr, http.StatusMethodNotAllowed)
+			assert.Equal(t, ProblemTypeMethodNotAllowed, problem.Type)
+			assert.Equal(t, "The endpoint supports POST requests", problem.Detail)
+			assert.Equal(t, http.MethodPost, rr.Header().Get("Allow"))
+		})
+	}
+}
@@ -0,0 +1,140 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Principal is the authenticated identity of a control plane calling the signaling API.
+type Principal struct {
+	// ID identifies the caller, e.g. the subject of a token or client certificate.
+	ID string
+	// Claims holds additional attributes of the caller, such as verified token claims.
+	Claims map[string]any
+}
+
+// Authenticator is an extension point for authenticating requests to the signaling API. Implementations return an
+// error wrapping ErrUnauthorized if the request carries no or invalid credentials.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator.
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of the context carrying the principal.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated caller of the current request. Processors and handlers can use it to
+// authorize operations.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// authenticate wraps a handler so that only authenticated requests reach it. The principal is placed in the request
+// context.
+func authenticate(authenticator Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authenticator.Authenticate(r)
+		if err != nil || principal == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			problem := unauthorizedProblem()
+			problem.CorrelationID, _ = CorrelationIDFromContext(r.Context())
+			writeProblem(w, problem)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), principal)))
+	})
+}
+
+// BearerTokenAuthenticator authenticates requests carrying one of a set of static bearer tokens.
+type BearerTokenAuthenticator struct {
+	tokens map[string]string
+}
+
+// NewBearerTokenAuthenticator creates an authenticator from a map of tokens to principal IDs.
+func NewBearerTokenAuthenticator(tokens map[string]string) *BearerTokenAuthenticator {
+	return &BearerTokenAuthenticator{tokens: tokens}
+}
+
+func (a *BearerTokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, err := bearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+	var principalID string
+	// compare against every token in constant time so that timing does not reveal valid prefixes
+	for candidate, id := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			principalID = id
+		}
+	}
+	if principalID == "" {
+		return nil, fmt.Errorf("%w: invalid bearer token", ErrUnauthorized)
+	}
+	return &Principal{ID: principalID}, nil
+}
+
+// MTLSAuthenticator authenticates requests by the subject of a verified TLS client certificate. The server must be
+// configured to request and verify client certificates, e.g. with tls.RequireAndVerifyClientCert.
+type MTLSAuthenticator struct {
+	subjects map[string]struct{}
+}
+
+// NewMTLSAuthenticator creates an authenticator that accepts client certificates whose subject distinguished name
+// (e.g. "CN=controlplane,O=Example") or common name matches one of the given subjects.
+func NewMTLSAuthenticator(subjects ...string) *MTLSAuthenticator {
+	allowed := make(map[string]struct{}, len(subjects))
+	for _, subject := range subjects {
+		allowed[subject] = struct{}{}
+	}
+	return &MTLSAuthenticator{subjects: allowed}
+}
+
+func (a *MTLSAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, fmt.Errorf("%w: no verified client certificate", ErrUnauthorized)
+	}
+	certificate := r.TLS.VerifiedChains[0][0]
+	subject := certificate.Subject.String()
+	if _, found := a.subjects[subject]; found {
+		return &Principal{ID: subject}, nil
+	}
+	if _, found := a.subjects[certificate.Subject.CommonName]; found && certificate.Subject.CommonName != "" {
+		return &Principal{ID: certificate.Subject.CommonName}, nil
+	}
+	return nil, fmt.Errorf("%w: client certificate subject %s is not allowed", ErrUnauthorized, subject)
+}
+
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", fmt.Errorf("%w: missing bearer token", ErrUnauthorized)
+	}
+	return strings.TrimSpace(token), nil
+}
@@ -0,0 +1,102 @@
+package dsdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignalingHandler_Authenticator(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().FindById(mock.Anything, "process123").Return(nil, ErrNotFound)
+	store.EXPECT().Create(mock.Anything, mock.AnythingOfType("*dsdk.DataFlow")).Return(nil)
+	var principal *Principal
+	onStart := func(ctx context.Context, _ *DataFlow, _ *DataPlaneSDK, _ *ProcessorOptions) (*DataFlowResponseMessage, error) {
+		principal, _ = PrincipalFromContext(ctx)
+		return &DataFlowResponseMessage{State: Started}, nil
+	}
+	handler := NewSignalingHandler(newSignalingApi(store, onStart), SignalingHandlerOptions{
+		Authenticator: NewBearerTokenAuthenticator(map[string]string{"secret": "controlplane"}),
+	})
+	payload, err := json.Marshal(createStartMessage())
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dataflows/start", bytes.NewReader(payload)))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+
+	req := httptest.NewRequest(http.MethodPost, "/dataflows/start", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.NotNil(t, principal)
+	assert.Equal(t, "controlplane", principal.ID)
+}
+
+func Test_BearerTokenAuthenticator(t *testing.T) {
+	authenticator := NewBearerTokenAuthenticator(map[string]string{"token1": "cp1", "token2": "cp2"})
+
+	tests := map[string]struct {
+		header string
+		want   string
+	}{
+		"first token":  {"Bearer token1", "cp1"},
+		"second token": {"bearer token2", "cp2"},
+		"wrong token":  {"Bearer token3", ""},
+		"wrong scheme": {"Basic token1", ""},
+		"missing":      {"", ""},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+			principal, err := authenticator.Authenticate(req)
+			if test.want == "" {
+				assert.ErrorIs(t, err, ErrUnauthorized)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, principal.ID)
+		})
+	}
+}
+
+func Test_MTLSAuthenticator(t *testing.T) {
+	authenticator := NewMTLSAuthenticator("controlplane", "CN=other,O=Example")
+
+	newRequest := func(subject *pkix.Name) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if subject != nil {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: *subject}}}}
+		}
+		return req
+	}
+
+	principal, err := authenticator.Authenticate(newRequest(&pkix.Name{CommonName: "controlplane", Organization: []string{"Example"}}))
+	require.NoError(t, err)
+	assert.Equal(t, "controlplane", principal.ID)
+
+	principal, err = authenticator.Authenticate(newRequest(&pkix.Name{CommonName: "other", Organization: []string{"Example"}}))
+	require.NoError(t, err)
+	assert.Equal(t, "CN=other,O=Example", principal.ID)
+
+	_, err = authenticator.Authenticate(newRequest(&pkix.Name{CommonName: "intruder"}))
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = authenticator.Authenticate(newRequest(nil))
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -0,0 +1,294 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// CallbackMessageVersion is the schema version of the state change messages sent to control plane callbacks.
+	CallbackMessageVersion = "1.0"
+	// SignatureHeader carries the request signature of a callback message.
+	SignatureHeader = "X-Dataplane-Signature"
+	// MessageVersionHeader carries the schema version of a callback message.
+	MessageVersionHeader = "X-Dataplane-Message-Version"
+)
+
+// ControlPlaneNotifier is an extension point for reporting asynchronous data flow state changes to the control plane.
+type ControlPlaneNotifier interface {
+	// Notify reports the current state of the flow to its callback address. The data address is optional.
+	Notify(ctx context.Context, flow *DataFlow, dataAddress *DataAddress) error
+}
+
+// RequestSigner signs outgoing callback requests so that the control plane can verify their origin.
+type RequestSigner interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// RetryPolicy defines how often and how fast a failed request is retried.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy returns a policy with five attempts and exponential backoff starting at 500ms.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// DefaultOutboxRetryPolicy returns the policy of the outbox relay: 20 deliveries with exponential backoff starting at 1s
+// and capped at 5m, which spans roughly an hour of control plane unavailability.
+func DefaultOutboxRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    20,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Minute,
+	}
+}
+
+// Backoff returns the delay before the given retry attempt, starting at 1 for the first retry. Without a MaxBackoff the
+// delay doubles up to the largest time.Duration.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		if delay > math.MaxInt64/2 {
+			return math.MaxInt64
+		}
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Wait blocks for the backoff of the given attempt or until the context is cancelled.
+func (p RetryPolicy) Wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.Backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// CallbackClient is the default ControlPlaneNotifier. It POSTs a DataFlowStateChangeMessage to the callback address of
+// the flow and retries transient failures according to its RetryPolicy.
+type CallbackClient struct {
+	httpClient *http.Client
+	signer     RequestSigner
+	retry      RetryPolicy
+}
+
+// Notify sends the current state of the flow to its callback address.
+func (c *CallbackClient) Notify(ctx context.Context, flow *DataFlow, dataAddress *DataAddress) error {
+	if flow == nil || flow.CallbackAddress.IsEmpty() {
+		return fmt.Errorf("%w: data flow has no callback address", ErrInvalidInput)
+	}
+
+	message := DataFlowStateChangeMessage{
+		Version:     CallbackMessageVersion,
+		MessageID:   uuid.NewString(),
+		ProcessID:   flow.ID,
+		State:       flow.State,
+		DataAddress: dataAddress,
+		Error:       flow.ErrorDetail,
+		Failure:     flow.Failure,
+		Timestamp:   time.Now().UnixMilli(),
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("serializing callback message for %s: %w", flow.ID, err)
+	}
+
+	callbackURL := flow.CallbackAddress.URL().String()
+	var lastErr error
+	for attempt := 1; attempt <= c.retry.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := c.retry.Wait(ctx, attempt-1); err != nil {
+				return fmt.Errorf("notifying control plane for %s: %w (last error: %v)", flow.ID, err, lastErr)
+			}
+		}
+		retryable, err := c.send(ctx, callbackURL, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable {
+			return fmt.Errorf("notifying control plane for %s: %w", flow.ID, err)
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("notifying control plane for %s: giving up after %d attempts: %w", flow.ID, c.retry.MaxAttempts, lastErr)
+}
+
+// send performs a single delivery attempt and reports whether a failure is worth retrying.
+func (c *CallbackClient) send(ctx context.Context, callbackURL string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set(contentType, jsonContentType)
+	req.Header.Set(MessageVersionHeader, CallbackMessageVersion)
+	// continue the trace of the operation that caused the state change, if any
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if c.signer != nil {
+		if err := c.signer.Sign(req, body); err != nil {
+			return false, fmt.Errorf("signing callback request: %w", err)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// a cancelled context is final, network errors are transient
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	return isRetryableStatus(resp.StatusCode), fmt.Errorf("callback returned status %d", resp.StatusCode)
+}
+
+func isRetryableStatus(code int) bool {
+	return code >= http.StatusInternalServerError ||
+		code == http.StatusRequestTimeout ||
+		code == http.StatusTooEarly ||
+		code == http.StatusTooManyRequests
+}
+
+type CallbackClientBuilder struct {
+	client *CallbackClient
+}
+
+func NewCallbackClientBuilder() *CallbackClientBuilder {
+	return &CallbackClientBuilder{
+		client: &CallbackClient{
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+			retry:      DefaultRetryPolicy(),
+		},
+	}
+}
+
+func (b *CallbackClientBuilder) HTTPClient(client *http.Client) *CallbackClientBuilder {
+	b.client.httpClient = client
+	return b
+}
+
+func (b *CallbackClientBuilder) Signer(signer RequestSigner) *CallbackClientBuilder {
+	b.client.signer = signer
+	return b
+}
+
+func (b *CallbackClientBuilder) RetryPolicy(policy RetryPolicy) *CallbackClientBuilder {
+	b.client.retry = policy
+	return b
+}
+
+func (b *CallbackClientBuilder) Build() (*CallbackClient, error) {
+	if b.client.httpClient == nil {
+		return nil, errors.New("http client is required")
+	}
+	if b.client.retry.MaxAttempts < 1 {
+		return nil, NewValidationError("retry policy requires at least one attempt")
+	}
+	return b.client, nil
+}
+
+// HMACSigner signs callback requests with an HMAC-SHA256 over a timestamp and the request body. The signature header has
+// the form "t=<unix seconds>,kid=<key id>,v1=<hex digest>".
+type HMACSigner struct {
+	keyID  string
+	secret []byte
+}
+
+func NewHMACSigner(keyID string, secret []byte) *HMACSigner {
+	return &HMACSigner{keyID: keyID, secret: secret}
+}
+
+func (s *HMACSigner) Sign(req *http.Request, body []byte) error {
+	if len(s.secret) == 0 {
+		return errors.New("signing secret is empty")
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(SignatureHeader, fmt.Sprintf("t=%s,kid=%s,v1=%s", timestamp, s.keyID, computeHMAC(s.secret, timestamp, body)))
+	return nil
+}
+
+// VerifyHMACSignature checks a signature header produced by HMACSigner. Signatures older than maxAge are rejected; a
+// zero maxAge disables the check.
+func VerifyHMACSignature(header string, body []byte, secret []byte, maxAge time.Duration) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("%w: malformed signature header", ErrValidation)
+	}
+	if maxAge > 0 {
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: invalid signature timestamp", ErrValidation)
+		}
+		if time.Since(time.Unix(seconds, 0)) > maxAge {
+			return fmt.Errorf("%w: signature expired", ErrValidation)
+		}
+	}
+	expected := computeHMAC(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("%w: signature mismatch", ErrValidation)
+	}
+	return nil
+}
+
+func computeHMAC(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
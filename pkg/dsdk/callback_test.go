@@ -0,0 +1,165 @@
+package dsdk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CallbackClient_Notify(t *testing.T) {
+	var received DataFlowStateChangeMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, CallbackMessageVersion, r.Header.Get(MessageVersionHeader))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := NewCallbackClientBuilder().Build()
+	require.NoError(t, err)
+
+	address, _ := NewDataAddressBuilder().Property("foo", "bar").Build()
+	err = client.Notify(context.Background(), newCallbackFlow(t, server.URL), address)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "flow123", received.ProcessID)
+	assert.Equal(t, Started, received.State)
+	assert.Equal(t, CallbackMessageVersion, received.Version)
+	assert.NotEmpty(t, received.MessageID)
+	assert.Equal(t, "bar", received.DataAddress.Properties["foo"])
+}
+
+func Test_CallbackClient_Notify_RetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewCallbackClientBuilder().RetryPolicy(fastRetryPolicy(5)).Build()
+	require.NoError(t, err)
+
+	err = client.Notify(context.Background(), newCallbackFlow(t, server.URL), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func Test_CallbackClient_Notify_GivesUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := NewCallbackClientBuilder().RetryPolicy(fastRetryPolicy(3)).Build()
+	require.NoError(t, err)
+
+	err = client.Notify(context.Background(), newCallbackFlow(t, server.URL), nil)
+
+	assert.ErrorContains(t, err, "giving up after 3 attempts")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func Test_CallbackClient_Notify_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client, err := NewCallbackClientBuilder().RetryPolicy(fastRetryPolicy(5)).Build()
+	require.NoError(t, err)
+
+	err = client.Notify(context.Background(), newCallbackFlow(t, server.URL), nil)
+
+	assert.ErrorContains(t, err, "status 400")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func Test_CallbackClient_Notify_ContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewCallbackClientBuilder().RetryPolicy(RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: time.Minute,
+	}).Build()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = client.Notify(ctx, newCallbackFlow(t, server.URL), nil)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func Test_CallbackClient_Notify_MissingCallbackAddress(t *testing.T) {
+	client, err := NewCallbackClientBuilder().Build()
+	require.NoError(t, err)
+
+	err = client.Notify(context.Background(), &DataFlow{ID: "flow123"}, nil)
+
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func Test_CallbackClient_Notify_Signed(t *testing.T) {
+	secret := []byte("secret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, VerifyHMACSignature(r.Header.Get(SignatureHeader), body, secret, time.Minute))
+		assert.ErrorIs(t, VerifyHMACSignature(r.Header.Get(SignatureHeader), body, []byte("other"), time.Minute), ErrValidation)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewCallbackClientBuilder().Signer(NewHMACSigner("key-1", secret)).Build()
+	require.NoError(t, err)
+
+	assert.NoError(t, client.Notify(context.Background(), newCallbackFlow(t, server.URL), nil))
+}
+
+func Test_RetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 300*time.Millisecond, policy.Backoff(3))
+	assert.Equal(t, 300*time.Millisecond, policy.Backoff(4))
+
+	unbounded := RetryPolicy{MaxAttempts: 100, InitialBackoff: 500 * time.Millisecond}
+	assert.Equal(t, time.Duration(math.MaxInt64), unbounded.Backoff(100))
+	assert.Positive(t, unbounded.Backoff(35))
+}
+
+func fastRetryPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+}
+
+func newCallbackFlow(t *testing.T, callback string) *DataFlow {
+	t.Helper()
+	u, err := url.Parse(callback)
+	require.NoError(t, err)
+	return &DataFlow{ID: "flow123", State: Started, CallbackAddress: CallbackURL(*u)}
+}
@@ -0,0 +1,364 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+// Package client provides a typed Go client for the Data Plane Signaling API exposed by dsdk.NewSignalingHandler.
+package client
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+)
+
+const (
+	contentType     = "Content-Type"
+	jsonContentType = "application/json"
+)
+
+// RequestAuthorizer adds credentials to outgoing signaling requests.
+type RequestAuthorizer interface {
+	Authorize(req *http.Request) error
+}
+
+// AuthorizerFunc adapts a function to a RequestAuthorizer.
+type AuthorizerFunc func(req *http.Request) error
+
+func (f AuthorizerFunc) Authorize(req *http.Request) error {
+	return f(req)
+}
+
+// BearerToken returns a RequestAuthorizer that sets a static bearer token.
+func BearerToken(token string) RequestAuthorizer {
+	return AuthorizerFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// Result is the outcome of a prepare or start request.
+type Result struct {
+	Message dsdk.DataFlowResponseMessage
+	// Accepted is true if the data plane completes the request asynchronously (HTTP 202).
+	Accepted bool
+	// Location is the data flow resource path returned with asynchronous responses.
+	Location string
+}
+
+// ResponseError is returned for non-successful responses. It unwraps to dsdk.ErrValidation, dsdk.ErrInvalidTransition,
+// dsdk.ErrUnauthorized, dsdk.ErrNotFound or dsdk.ErrConflict depending on the problem type or, for responses without
+// problem details, the status code.
+type ResponseError struct {
+	StatusCode int
+	Message    string
+	// Problem contains the problem details of the response, if any.
+	Problem *dsdk.Problem
+	// CorrelationID identifies the request in the log of the data plane.
+	CorrelationID string
+	sentinel      error
+}
+
+func (e *ResponseError) Error() string {
+	message := fmt.Sprintf("signaling request failed with status %d", e.StatusCode)
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	if e.CorrelationID != "" {
+		message += fmt.Sprintf(" (correlation ID %s)", e.CorrelationID)
+	}
+	return message
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.sentinel
+}
+
+// Client sends Data Plane Signaling messages to a data plane.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	authorizer RequestAuthorizer
+	retry      dsdk.RetryPolicy
+}
+
+// Prepare sends a prepare message to a consumer data plane.
+func (c *Client) Prepare(ctx context.Context, message dsdk.DataFlowPrepareMessage) (*Result, error) {
+	return c.postForResult(ctx, "/dataflows/prepare", message, true)
+}
+
+// Start sends a start message for a new data flow.
+func (c *Client) Start(ctx context.Context, message dsdk.DataFlowStartMessage) (*Result, error) {
+	return c.postForResult(ctx, "/dataflows/start", message, true)
+}
+
+// StartById starts an existing data flow, for example a prepared consumer flow or a suspended flow.
+func (c *Client) StartById(ctx context.Context, processID string, message dsdk.DataFlowStartByIdMessage) (*Result, error) {
+	return c.postForResult(ctx, flowPath(processID, "start"), message, false)
+}
+
+// Suspend suspends a data flow. The reason is optional.
+func (c *Client) Suspend(ctx context.Context, processID string, reason string) error {
+	_, err := c.do(ctx, http.MethodPost, flowPath(processID, "suspend"), transitionBody(reason), false)
+	return err
+}
+
+// Terminate terminates a data flow. The reason is optional.
+func (c *Client) Terminate(ctx context.Context, processID string, reason string) error {
+	_, err := c.do(ctx, http.MethodPost, flowPath(processID, "terminate"), transitionBody(reason), false)
+	return err
+}
+
+// Complete marks a data flow as completed.
+func (c *Client) Complete(ctx context.Context, processID string) error {
+	_, err := c.do(ctx, http.MethodPost, flowPath(processID, "completed"), nil, false)
+	return err
+}
+
+// Status returns the state of a data flow.
+func (c *Client) Status(ctx context.Context, processID string) (*dsdk.DataFlowStatusResponseMessage, error) {
+	resp, err := c.do(ctx, http.MethodGet, flowPath(processID, "status"), nil, true)
+	if err != nil {
+		return nil, err
+	}
+	var status dsdk.DataFlowStatusResponseMessage
+	if err := json.Unmarshal(resp.body, &status); err != nil {
+		return nil, fmt.Errorf("decoding status response: %w", err)
+	}
+	return &status, nil
+}
+
+// History returns the state transitions of a data flow in the order they were applied.
+func (c *Client) History(ctx context.Context, processID string) ([]dsdk.StateTransition, error) {
+	resp, err := c.do(ctx, http.MethodGet, flowPath(processID, "history"), nil, true)
+	if err != nil {
+		return nil, err
+	}
+	var history dsdk.DataFlowHistoryResponseMessage
+	if err := json.Unmarshal(resp.body, &history); err != nil {
+		return nil, fmt.Errorf("decoding history response: %w", err)
+	}
+	return history.Transitions, nil
+}
+
+func (c *Client) postForResult(ctx context.Context, path string, message any, idempotent bool) (*Result, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("serializing message: %w", err)
+	}
+	resp, err := c.do(ctx, http.MethodPost, path, body, idempotent)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Accepted: resp.statusCode == http.StatusAccepted,
+		Location: resp.location,
+	}
+	if err := json.Unmarshal(resp.body, &result.Message); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return result, nil
+}
+
+type response struct {
+	statusCode int
+	location   string
+	body       []byte
+}
+
+// do sends the request. Idempotent requests are retried on network errors and transient status codes: reads, and prepare
+// and start messages, which a data plane with an IdempotencyStore answers with the original response when their message
+// ID is replayed. Other state changes are sent once, because repeating one that was processed fails or applies it twice.
+func (c *Client) do(ctx context.Context, method string, path string, body []byte, idempotent bool) (*response, error) {
+	attempts := 1
+	if idempotent {
+		attempts = c.retry.MaxAttempts
+	}
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			if err := c.retry.Wait(ctx, attempt-1); err != nil {
+				return nil, fmt.Errorf("%w (last error: %v)", err, lastErr)
+			}
+		}
+		resp, retryable, err := c.send(ctx, method, path, body)
+		if err == nil {
+			return resp, nil
+		}
+		if !retryable {
+			return nil, err
+		}
+		lastErr = err
+	}
+	if attempts == 1 {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", attempts, lastErr)
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, body []byte) (*response, bool, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, false, err
+	}
+	if body != nil {
+		req.Header.Set(contentType, jsonContentType)
+	}
+	if correlationID, found := dsdk.CorrelationIDFromContext(ctx); found {
+		req.Header.Set(dsdk.CorrelationIDHeader, correlationID)
+	}
+	if c.authorizer != nil {
+		if err := c.authorizer.Authorize(req); err != nil {
+			return nil, false, fmt.Errorf("authorizing request: %w", err)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusAccepted {
+		return &response{statusCode: resp.StatusCode, location: resp.Header.Get("Location"), body: responseBody}, false, nil
+	}
+	responseErr := newResponseError(resp.StatusCode, resp.Header.Get(contentType), responseBody)
+	if responseErr.CorrelationID == "" {
+		responseErr.CorrelationID = resp.Header.Get(dsdk.CorrelationIDHeader)
+	}
+	return nil, isRetryableStatus(resp.StatusCode), responseErr
+}
+
+func newResponseError(statusCode int, mediaType string, body []byte) *ResponseError {
+	responseErr := &ResponseError{StatusCode: statusCode}
+	if strings.HasPrefix(mediaType, dsdk.ProblemContentType) {
+		var problem dsdk.Problem
+		if err := json.Unmarshal(body, &problem); err == nil {
+			responseErr.Problem = &problem
+			responseErr.Message = cmp.Or(problem.Detail, problem.Title)
+			responseErr.CorrelationID = problem.CorrelationID
+			if sentinel, found := problemSentinels[problem.Type]; found {
+				responseErr.sentinel = sentinel
+				return responseErr
+			}
+		}
+	} else {
+		// data planes that do not return problem details
+		var message dsdk.DataFlowResponseMessage
+		if err := json.Unmarshal(body, &message); err == nil {
+			responseErr.Message = message.Error
+		}
+	}
+	switch statusCode {
+	case http.StatusBadRequest:
+		responseErr.sentinel = dsdk.ErrValidation
+	case http.StatusUnauthorized:
+		responseErr.sentinel = dsdk.ErrUnauthorized
+	case http.StatusNotFound:
+		responseErr.sentinel = dsdk.ErrNotFound
+	case http.StatusConflict:
+		responseErr.sentinel = dsdk.ErrConflict
+	}
+	return responseErr
+}
+
+// problemSentinels maps the problem types of the signaling API to sentinel errors.
+var problemSentinels = map[string]error{
+	dsdk.ProblemTypeValidation:        dsdk.ErrValidation,
+	dsdk.ProblemTypeInvalidTransition: dsdk.ErrInvalidTransition,
+	dsdk.ProblemTypeNotFound:          dsdk.ErrNotFound,
+	dsdk.ProblemTypeConflict:          dsdk.ErrConflict,
+	dsdk.ProblemTypeUnauthorized:      dsdk.ErrUnauthorized,
+}
+
+func isRetryableStatus(code int) bool {
+	return code >= http.StatusInternalServerError ||
+		code == http.StatusRequestTimeout ||
+		code == http.StatusTooEarly ||
+		code == http.StatusTooManyRequests
+}
+
+func flowPath(processID string, operation string) string {
+	return "/dataflows/" + url.PathEscape(processID) + "/" + operation
+}
+
+func transitionBody(reason string) []byte {
+	if reason == "" {
+		return nil
+	}
+	body, _ := json.Marshal(dsdk.DataFlowTransitionMessage{Reason: reason})
+	return body
+}
+
+type ClientBuilder struct {
+	client *Client
+}
+
+// NewClientBuilder creates a builder for a client that targets the given base URL, including any base path and version
+// prefix configured on the data plane, e.g. "https://dataplane.example.com/api/signaling/v1".
+func NewClientBuilder(baseURL string) *ClientBuilder {
+	return &ClientBuilder{
+		client: &Client{
+			baseURL:    strings.TrimSuffix(baseURL, "/"),
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+			retry:      dsdk.RetryPolicy{MaxAttempts: 1},
+		},
+	}
+}
+
+func (b *ClientBuilder) HTTPClient(client *http.Client) *ClientBuilder {
+	b.client.httpClient = client
+	return b
+}
+
+func (b *ClientBuilder) Authorizer(authorizer RequestAuthorizer) *ClientBuilder {
+	b.client.authorizer = authorizer
+	return b
+}
+
+// RetryPolicy sets the retries of idempotent requests: status and history reads, and prepare and start messages. Other
+// requests are sent once. Defaults to a single attempt.
+func (b *ClientBuilder) RetryPolicy(policy dsdk.RetryPolicy) *ClientBuilder {
+	b.client.retry = policy
+	return b
+}
+
+func (b *ClientBuilder) Build() (*Client, error) {
+	if _, err := url.ParseRequestURI(b.client.baseURL); err != nil {
+		return nil, dsdk.WrapValidationError(err)
+	}
+	if b.client.httpClient == nil {
+		return nil, errors.New("http client is required")
+	}
+	if b.client.retry.MaxAttempts < 1 {
+		return nil, dsdk.NewValidationError("retry policy requires at least one attempt")
+	}
+	return b.client, nil
+}
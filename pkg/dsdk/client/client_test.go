@@ -0,0 +1,334 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+	"github.com/metaform/dataplane-sdk-go/pkg/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_Prepare(t *testing.T) {
+	c := newClient(t, newDataPlane(t, nil), "")
+
+	result, err := c.Prepare(context.Background(), newPrepareMessage())
+
+	require.NoError(t, err)
+	assert.False(t, result.Accepted)
+	assert.Equal(t, dsdk.Prepared, result.Message.State)
+}
+
+func Test_Client_Start(t *testing.T) {
+	c := newClient(t, newDataPlane(t, nil), "")
+
+	result, err := c.Start(context.Background(), newStartMessage())
+
+	require.NoError(t, err)
+	assert.False(t, result.Accepted)
+	assert.Empty(t, result.Location)
+	assert.Equal(t, dsdk.Started, result.Message.State)
+}
+
+func Test_Client_Start_Accepted(t *testing.T) {
+	onStart := func(_ context.Context, flow *dsdk.DataFlow, _ *dsdk.DataPlaneSDK, _ *dsdk.ProcessorOptions) (*dsdk.DataFlowResponseMessage, error) {
+		return &dsdk.DataFlowResponseMessage{State: dsdk.Starting}, nil
+	}
+	c := newClient(t, newDataPlane(t, onStart), "/api/v1")
+	message := newStartMessage()
+
+	result, err := c.Start(context.Background(), message)
+
+	require.NoError(t, err)
+	assert.True(t, result.Accepted)
+	assert.Equal(t, "/api/v1/dataflows/"+message.ProcessID, result.Location)
+	assert.Equal(t, dsdk.Starting, result.Message.State)
+}
+
+func Test_Client_Lifecycle(t *testing.T) {
+	c := newClient(t, newDataPlane(t, nil), "")
+	ctx := context.Background()
+	message := newStartMessage()
+
+	_, err := c.Start(ctx, message)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Suspend(ctx, message.ProcessID, "maintenance"))
+	status, err := c.Status(ctx, message.ProcessID)
+	require.NoError(t, err)
+	assert.Equal(t, dsdk.Suspended, status.State)
+	assert.Equal(t, message.ProcessID, status.DataFlowID)
+
+	result, err := c.StartById(ctx, message.ProcessID, dsdk.DataFlowStartByIdMessage{
+		MessageID:         "resume-1",
+		SourceDataAddress: message.SourceDataAddress,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dsdk.Started, result.Message.State)
+	history, err := c.History(ctx, message.ProcessID)
+	require.NoError(t, err)
+	assert.Equal(t, "resume-1", history[len(history)-1].MessageID)
+
+	require.NoError(t, c.Complete(ctx, message.ProcessID))
+	status, err = c.Status(ctx, message.ProcessID)
+	require.NoError(t, err)
+	assert.Equal(t, dsdk.Completed, status.State)
+}
+
+func Test_Client_Terminate(t *testing.T) {
+	c := newClient(t, newDataPlane(t, nil), "")
+	ctx := context.Background()
+	message := newStartMessage()
+
+	_, err := c.Start(ctx, message)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Terminate(ctx, message.ProcessID, "violation"))
+	status, err := c.Status(ctx, message.ProcessID)
+	require.NoError(t, err)
+	assert.Equal(t, dsdk.Terminated, status.State)
+	require.NotNil(t, status.Failure)
+	assert.Equal(t, dsdk.FailureCodeTerminated, status.Failure.Code)
+	assert.Equal(t, "violation", status.Failure.Message)
+	assert.Equal(t, dsdk.FailureOriginControlPlane, status.Failure.Origin)
+	assert.NotZero(t, status.Failure.Timestamp)
+}
+
+func Test_Client_History(t *testing.T) {
+	c := newClient(t, newDataPlane(t, nil), "")
+	ctx := context.Background()
+	message := newStartMessage()
+
+	_, err := c.Start(ctx, message)
+	require.NoError(t, err)
+	require.NoError(t, c.Suspend(ctx, message.ProcessID, "maintenance"))
+	require.NoError(t, c.Terminate(ctx, message.ProcessID, "violation"))
+
+	history, err := c.History(ctx, message.ProcessID)
+
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, dsdk.Uninitialized, history[0].FromState)
+	assert.Equal(t, dsdk.Starting, history[0].ToState)
+	assert.Equal(t, message.MessageID, history[0].MessageID)
+	assert.Equal(t, dsdk.Started, history[1].ToState)
+	assert.Equal(t, dsdk.Suspended, history[2].ToState)
+	assert.Equal(t, "maintenance", history[2].Reason)
+	assert.Equal(t, dsdk.Suspended, history[3].FromState)
+	assert.Equal(t, dsdk.Terminated, history[3].ToState)
+	assert.Equal(t, "violation", history[3].Reason)
+
+	_, err = c.History(ctx, "unknown")
+	assert.ErrorIs(t, err, dsdk.ErrNotFound)
+}
+
+func Test_Client_Start_ReplayAfterTerminate(t *testing.T) {
+	sdk, err := dsdk.NewDataPlaneSDKBuilder().
+		Store(memory.NewInMemoryStore()).
+		TransactionContext(memory.InMemoryTrxContext{}).
+		IdempotencyStore(memory.NewInMemoryIdempotencyStore()).
+		Build()
+	require.NoError(t, err)
+	c := newClient(t, sdk, "")
+	ctx := context.Background()
+	message := newStartMessage()
+
+	_, err = c.Start(ctx, message)
+	require.NoError(t, err)
+	require.NoError(t, c.Terminate(ctx, message.ProcessID, ""))
+
+	result, err := c.Start(ctx, message)
+
+	require.NoError(t, err)
+	assert.Equal(t, dsdk.Started, result.Message.State)
+}
+
+func Test_Client_ErrorMapping(t *testing.T) {
+	c := newClient(t, newDataPlane(t, nil), "")
+	ctx := context.Background()
+
+	_, err := c.Status(ctx, "unknown")
+	assert.ErrorIs(t, err, dsdk.ErrNotFound)
+
+	message := newStartMessage()
+	message.CounterPartyID = ""
+	_, err = c.Start(ctx, message)
+	assert.ErrorIs(t, err, dsdk.ErrValidation)
+
+	var responseErr *ResponseError
+	require.ErrorAs(t, err, &responseErr)
+	assert.Equal(t, http.StatusBadRequest, responseErr.StatusCode)
+	assert.NotEmpty(t, responseErr.Message)
+	require.NotNil(t, responseErr.Problem)
+	assert.Contains(t, responseErr.Problem.Violations, dsdk.FieldViolation{Field: "counterPartyID", Rule: "required", Message: "is required"})
+	assert.NotEmpty(t, responseErr.CorrelationID)
+}
+
+func Test_Client_ErrorMapping_Problem(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "request-123", r.Header.Get(dsdk.CorrelationIDHeader))
+		w.Header().Set("Content-Type", dsdk.ProblemContentType)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"type":"urn:dsdk:problem:invalid-transition","title":"Invalid state transition","status":400,` +
+			`"detail":"flow is terminated","correlationId":"request-123"}`))
+	}))
+	defer server.Close()
+	c, err := NewClientBuilder(server.URL).Build()
+	require.NoError(t, err)
+	ctx := dsdk.ContextWithCorrelationID(context.Background(), "request-123")
+
+	err = c.Complete(ctx, "flow123")
+
+	assert.ErrorIs(t, err, dsdk.ErrInvalidTransition)
+	assert.ErrorContains(t, err, "correlation ID request-123")
+	var responseErr *ResponseError
+	require.ErrorAs(t, err, &responseErr)
+	assert.Equal(t, "flow is terminated", responseErr.Message)
+	assert.Equal(t, "request-123", responseErr.CorrelationID)
+	assert.Equal(t, dsdk.ProblemTypeInvalidTransition, responseErr.Problem.Type)
+}
+
+func Test_Client_ErrorMapping_Conflict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+	c, err := NewClientBuilder(server.URL).Build()
+	require.NoError(t, err)
+
+	_, err = c.Start(context.Background(), newStartMessage())
+
+	assert.ErrorIs(t, err, dsdk.ErrConflict)
+}
+
+func Test_Client_Authorizer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	c, err := NewClientBuilder(server.URL).Authorizer(BearerToken("token")).Build()
+	require.NoError(t, err)
+
+	assert.NoError(t, c.Complete(context.Background(), "flow123"))
+}
+
+func Test_Client_RetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"dataFlowID":"flow123"}`))
+	}))
+	defer server.Close()
+	c, err := NewClientBuilder(server.URL).RetryPolicy(fastRetryPolicy(5)).Build()
+	require.NoError(t, err)
+
+	_, err = c.Status(context.Background(), "flow123")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func Test_Client_DoesNotRetryStateChanges(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c, err := NewClientBuilder(server.URL).RetryPolicy(fastRetryPolicy(5)).Build()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var responseErr *ResponseError
+	require.ErrorAs(t, c.Terminate(ctx, "flow123", "reason"), &responseErr)
+	assert.Equal(t, http.StatusServiceUnavailable, responseErr.StatusCode)
+	assert.Error(t, c.Suspend(ctx, "flow123", "reason"))
+	_, err = c.StartById(ctx, "flow123", dsdk.DataFlowStartByIdMessage{})
+	assert.Error(t, err)
+	assert.Error(t, c.Complete(ctx, "flow123"))
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func Test_Client_GivesUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	c, err := NewClientBuilder(server.URL).RetryPolicy(fastRetryPolicy(2)).Build()
+	require.NoError(t, err)
+
+	_, err = c.Start(context.Background(), newStartMessage())
+
+	assert.ErrorContains(t, err, "giving up after 2 attempts")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func Test_ClientBuilder_InvalidURL(t *testing.T) {
+	_, err := NewClientBuilder("not a url").Build()
+
+	assert.ErrorIs(t, err, dsdk.ErrValidation)
+}
+
+func newDataPlane(t *testing.T, onStart dsdk.DataFlowProcessor) *dsdk.DataPlaneSDK {
+	t.Helper()
+	builder := dsdk.NewDataPlaneSDKBuilder().
+		Store(memory.NewInMemoryStore()).
+		TransactionContext(memory.InMemoryTrxContext{})
+	if onStart != nil {
+		builder.OnStart(onStart)
+	}
+	sdk, err := builder.Build()
+	require.NoError(t, err)
+	return sdk
+}
+
+func newClient(t *testing.T, sdk *dsdk.DataPlaneSDK, basePath string) *Client {
+	t.Helper()
+	handler := dsdk.NewSignalingHandler(dsdk.NewDataPlaneApi(sdk), dsdk.SignalingHandlerOptions{BasePath: basePath})
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewClientBuilder(server.URL + basePath).Build()
+	require.NoError(t, err)
+	return c
+}
+
+func fastRetryPolicy(attempts int) dsdk.RetryPolicy {
+	return dsdk.RetryPolicy{MaxAttempts: attempts, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+}
+
+func newBaseMessage() dsdk.DataFlowBaseMessage {
+	return dsdk.DataFlowBaseMessage{
+		MessageID:        uuid.NewString(),
+		ParticipantID:    uuid.NewString(),
+		CounterPartyID:   uuid.NewString(),
+		DataspaceContext: uuid.NewString(),
+		ProcessID:        uuid.NewString(),
+		AgreementID:      uuid.NewString(),
+		DatasetID:        uuid.NewString(),
+		CallbackAddress:  dsdk.CallbackURL{Scheme: "http", Host: "test.com", Path: "/callback"},
+		TransferType:     dsdk.TransferType{DestinationType: "custom", FlowType: dsdk.Pull},
+	}
+}
+
+func newStartMessage() dsdk.DataFlowStartMessage {
+	return dsdk.DataFlowStartMessage{
+		DataFlowBaseMessage: newBaseMessage(),
+		SourceDataAddress:   &dsdk.DataAddress{Properties: map[string]any{"foo": "bar"}},
+	}
+}
+
+func newPrepareMessage() dsdk.DataFlowPrepareMessage {
+	return dsdk.DataFlowPrepareMessage{DataFlowBaseMessage: newBaseMessage()}
+}
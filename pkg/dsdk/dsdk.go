@@ -4,62 +4,102 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DataFlowProcessor is an extension point for handling SDK data flow events. Implementations may modify the data flow instance
 // which will be persisted by the SDK. If the message is a duplicate, implementations must support idempotent behavior.
+//
+// Processors run in the transaction that loaded the flow, which holds the flow lock of stores such as the Postgres store
+// until the processor returns. They must therefore not wait for a NotifyPrepared, NotifyStarted or other Notify call on
+// the same flow: that call runs in a new transaction and blocks on the lock, a deadlock. Processors report the state
+// in their response instead, or trigger the Notify call asynchronously and return without waiting for it.
 type DataFlowProcessor func(context context.Context, flow *DataFlow, sdk *DataPlaneSDK, options *ProcessorOptions) (*DataFlowResponseMessage, error)
 
 type ProcessorOptions struct {
 	Duplicate         bool
 	SourceDataAddress *DataAddress
+	// SuspensionReason is set when a suspended flow is resumed and contains the reason it was suspended with.
+	SuspensionReason string
+	// Retry is set when the flow timed out in PREPARING or STARTING and the processor is invoked again. See StateTimeout.
+	Retry bool
 }
 
 type DataFlowHandler func(context.Context, *DataFlow) error
 
-type LogMonitor interface {
-	Println(v ...any)
-	Printf(format string, v ...any)
-}
-
 type DataPlaneSDK struct {
 	Store      DataplaneStore
 	TrxContext TransactionContext
-	Monitor    LogMonitor
+	// Logger receives the log records of the SDK. The builder creates it from the configured slog.Handler, see
+	// DataPlaneSDKBuilder.LogHandler.
+	Logger   *slog.Logger
+	Notifier ControlPlaneNotifier
+	// RuntimeID identifies this runtime in clustered deployments. Flows are owned by the runtime that created them.
+	RuntimeID string
+	// IdempotencyStore enables answering replayed prepare and start messages with their original response. Optional.
+	IdempotencyStore IdempotencyStore
+	// OutboxStore enables writing control plane notifications in the transaction of the state change. They are delivered
+	// by RelayOutbox. Optional; without it, notifications are sent directly after the transaction has committed.
+	OutboxStore OutboxStore
+
+	idempotencyRetention time.Duration
+	leaseDuration        time.Duration
+	stateTimeouts        map[DataFlowState]StateTimeout
+	retention            *RetentionPolicy
+	archiver             FlowArchiver
+	outboxRetry          RetryPolicy
+	tracer               trace.Tracer
+	metrics              *Metrics
+
+	events eventBus
 
 	onPrepare   DataFlowProcessor
 	onStart     DataFlowProcessor
+	onResume    DataFlowProcessor
+	onRecover   DataFlowProcessor
 	onTerminate DataFlowHandler
 	onSuspend   DataFlowHandler
+	onComplete  DataFlowHandler
 }
 
 // Prepare is called on the consumer to prepare for receiving data.
 // It invokes the onPrepare callback and persists the created flow. Returns a response or an error if the process fails.
-func (dsdk *DataPlaneSDK) Prepare(ctx context.Context, message DataFlowPrepareMessage) (*DataFlowResponseMessage, error) {
+func (dsdk *DataPlaneSDK) Prepare(ctx context.Context, message DataFlowPrepareMessage) (_ *DataFlowResponseMessage, err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.Prepare", AttributeFlowID.String(message.ProcessID))
+	defer func() { endSpan(span, err) }()
 	processID := message.ProcessID
 	if processID == "" {
 		return nil, errors.New("processID cannot be empty")
 	}
 	var response *DataFlowResponseMessage
-	err := dsdk.execute(ctx, func(context.Context) error {
+	err = dsdk.execute(ctx, func(ctx context.Context) error {
 		flow, err := dsdk.Store.FindById(ctx, processID)
 		if err != nil && !errors.Is(err, ErrNotFound) {
 			return fmt.Errorf("performing de-duplication for %s: %w", processID, err)
 		}
 
+		if replayed, err := dsdk.replayedResponse(ctx, message.DataFlowBaseMessage); err != nil || replayed != nil {
+			response = replayed
+			return err
+		}
+
 		switch {
 		case flow != nil && (flow.State == Preparing || flow.State == Prepared):
 			// duplicate message, pass to handler to generate a data address if needed (on consumer)
+			updateAddresses(flow, nil, &message.DestinationDataAddress)
 			response, err = dsdk.onPrepare(ctx, flow, dsdk, &ProcessorOptions{Duplicate: true})
 			if err != nil {
 				return fmt.Errorf("processing data flow: %w", err)
 			}
 			// todo: not sure about this, added because Prepare() has it too
-			if err := dsdk.Store.Save(ctx, flow); err != nil {
+			if err := dsdk.save(ctx, flow); err != nil {
 				return fmt.Errorf("creating data flow: %w", err)
 			}
-			return nil
+			return dsdk.recordResponse(ctx, message.DataFlowBaseMessage, response)
 		case flow != nil:
 			return fmt.Errorf("%w: data flow %s is not in PREPARING or PREPARED state but in %s", ErrConflict, flow.ID, flow.State.String())
 			//return NewConflictError(fmt.Sprintf("data flow %s is not in PREPARING or PREPARED state", flow.ID))
@@ -74,11 +114,15 @@ func (dsdk *DataPlaneSDK) Prepare(ctx context.Context, message DataFlowPrepareMe
 			DataspaceContext(message.DataspaceContext).
 			TransferType(message.TransferType).
 			CallbackAddress(message.CallbackAddress).
+			DestinationDataAddress(message.DestinationDataAddress).
+			RuntimeID(dsdk.RuntimeID).
+			LeaseExpiry(dsdk.leaseExpiry()).
 			Build()
 
 		if err != nil {
 			return fmt.Errorf("creating data flow: %w", err)
 		}
+		flow.recordCreation()
 
 		response, err = dsdk.onPrepare(ctx, flow, dsdk, &ProcessorOptions{})
 		if err != nil {
@@ -97,10 +141,10 @@ func (dsdk *DataPlaneSDK) Prepare(ctx context.Context, message DataFlowPrepareMe
 		} else {
 			return fmt.Errorf("onPrepare returned an invalid state %s", response.State)
 		}
-		if err := dsdk.Store.Create(ctx, flow); err != nil {
+		if err := dsdk.create(ctx, flow); err != nil {
 			return fmt.Errorf("creating data flow %s: %w", flow.ID, err)
 		}
-		return nil
+		return dsdk.recordResponse(ctx, message.DataFlowBaseMessage, response)
 	})
 
 	// fixme: shouldn't we always return a clean nil/error or response/nil tuple?
@@ -109,18 +153,25 @@ func (dsdk *DataPlaneSDK) Prepare(ctx context.Context, message DataFlowPrepareMe
 
 // Start is called on the provider and starts a data flow based on the given start message and execution context.
 // It invokes the onStart callback and persists the created flow. Returns a response or an error if the process fails.
-func (dsdk *DataPlaneSDK) Start(ctx context.Context, message DataFlowStartMessage) (*DataFlowResponseMessage, error) {
+func (dsdk *DataPlaneSDK) Start(ctx context.Context, message DataFlowStartMessage) (_ *DataFlowResponseMessage, err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.Start", AttributeFlowID.String(message.ProcessID))
+	defer func() { endSpan(span, err) }()
 	processID := message.ProcessID
 	if processID == "" {
 		return nil, errors.New("processID cannot be empty")
 	}
 	var response *DataFlowResponseMessage
-	err := dsdk.execute(ctx, func(context.Context) error {
+	err = dsdk.execute(ctx, func(ctx context.Context) error {
 		flow, err := dsdk.Store.FindById(ctx, processID)
 		if err != nil && !errors.Is(err, ErrNotFound) {
 			return fmt.Errorf("performing de-duplication for %s: %w", processID, err)
 		}
 
+		if replayed, err := dsdk.replayedResponse(ctx, message.DataFlowBaseMessage); err != nil || replayed != nil {
+			response = replayed
+			return err
+		}
+
 		if flow == nil {
 			// provider side, process
 			flow, err = NewDataFlowBuilder().ID(processID).
@@ -132,10 +183,15 @@ func (dsdk *DataPlaneSDK) Start(ctx context.Context, message DataFlowStartMessag
 				DataspaceContext(message.DataspaceContext).
 				TransferType(message.TransferType).
 				CallbackAddress(message.CallbackAddress).
+				DestinationDataAddress(message.DestinationDataAddress).
+				RuntimeID(dsdk.RuntimeID).
+				LeaseExpiry(dsdk.leaseExpiry()).
 				Build()
 			if err != nil {
 				return fmt.Errorf("creating data flow: %w", err)
 			}
+			flow.recordCreation()
+			updateAddresses(flow, message.SourceDataAddress, nil)
 			response, err = dsdk.onStart(ctx, flow, dsdk, &ProcessorOptions{SourceDataAddress: message.SourceDataAddress})
 			if err != nil {
 				return fmt.Errorf("processing data flow: %w", err)
@@ -146,24 +202,29 @@ func (dsdk *DataPlaneSDK) Start(ctx context.Context, message DataFlowStartMessag
 				return fmt.Errorf("onStart returned an invalid state: %w", err)
 			}
 
-			if err := dsdk.Store.Create(ctx, flow); err != nil {
+			if err := dsdk.create(ctx, flow); err != nil {
 				return fmt.Errorf("creating data flow: %w", err)
 			}
-			return nil
+			return dsdk.recordResponse(ctx, message.DataFlowBaseMessage, response)
 		}
 
-		response, err = dsdk.startExistingFlow(ctx, flow, message.SourceDataAddress)
-		return err
+		response, err = dsdk.startExistingFlow(ctx, flow, message.SourceDataAddress, &message.DestinationDataAddress)
+		if err != nil {
+			return err
+		}
+		return dsdk.recordResponse(ctx, message.DataFlowBaseMessage, response)
 	})
 
 	return response, err
 
 }
 
-func (dsdk *DataPlaneSDK) StartById(ctx context.Context, processID string, message DataFlowStartByIdMessage) (*DataFlowResponseMessage, error) {
+func (dsdk *DataPlaneSDK) StartById(ctx context.Context, processID string, message DataFlowStartByIdMessage) (_ *DataFlowResponseMessage, err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.StartById", AttributeFlowID.String(processID))
+	defer func() { endSpan(span, err) }()
 	var response *DataFlowResponseMessage
 
-	err := dsdk.execute(ctx, func(ctx context.Context) error {
+	err = dsdk.execute(ctx, func(ctx context.Context) error {
 		existingFlow, err := dsdk.Store.FindById(ctx, processID)
 		if err != nil && !errors.Is(err, ErrNotFound) {
 			return fmt.Errorf("performing de-duplication for %s: %w", processID, err)
@@ -173,7 +234,7 @@ func (dsdk *DataPlaneSDK) StartById(ctx context.Context, processID string, messa
 			return ErrNotFound
 		}
 
-		response, err = dsdk.startExistingFlow(ctx, existingFlow, message.SourceDataAddress)
+		response, err = dsdk.startExistingFlow(ctx, existingFlow, message.SourceDataAddress, nil)
 		return err
 
 	})
@@ -181,7 +242,17 @@ func (dsdk *DataPlaneSDK) StartById(ctx context.Context, processID string, messa
 
 }
 
+// Terminate terminates a flow on request of the control plane. The reason is recorded as the message of a failure with
+// FailureCodeTerminated.
 func (dsdk *DataPlaneSDK) Terminate(ctx context.Context, processID string, reason string) error {
+	return dsdk.TerminateWithFailure(ctx, processID, Failure{Code: FailureCodeTerminated, Message: reason, Origin: FailureOriginControlPlane})
+}
+
+// TerminateWithFailure terminates a flow and records the failure that caused the termination. The origin of the
+// failure defaults to FailureOriginLocal.
+func (dsdk *DataPlaneSDK) TerminateWithFailure(ctx context.Context, processID string, failure Failure) (err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.Terminate", AttributeFlowID.String(processID))
+	defer func() { endSpan(span, err) }()
 	if processID == "" {
 		return errors.New("processID cannot be empty")
 	}
@@ -200,12 +271,12 @@ func (dsdk *DataPlaneSDK) Terminate(ctx context.Context, processID string, reaso
 			return fmt.Errorf("terminating data flow %s: %w", flow.ID, err)
 		}
 
-		err = flow.TransitionToTerminated(reason)
+		err = flow.terminate(failure)
 		if err != nil {
 			return err
 		}
 
-		err = dsdk.Store.Save(ctx, flow)
+		err = dsdk.save(ctx, flow)
 		if err != nil {
 			return fmt.Errorf("terminating data flow %s: %w", flow.ID, err)
 		}
@@ -213,7 +284,17 @@ func (dsdk *DataPlaneSDK) Terminate(ctx context.Context, processID string, reaso
 	})
 }
 
+// Suspend suspends a flow on request of the control plane. The reason is recorded as the message of a retryable failure
+// with FailureCodeSuspended and passed to the onResume processor when the flow is resumed.
 func (dsdk *DataPlaneSDK) Suspend(ctx context.Context, processID string, reason string) error {
+	return dsdk.SuspendWithFailure(ctx, processID, Failure{Code: FailureCodeSuspended, Message: reason, Retryable: true, Origin: FailureOriginControlPlane})
+}
+
+// SuspendWithFailure suspends a flow and records the failure that caused the suspension. The origin of the failure
+// defaults to FailureOriginLocal.
+func (dsdk *DataPlaneSDK) SuspendWithFailure(ctx context.Context, processID string, failure Failure) (err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.Suspend", AttributeFlowID.String(processID))
+	defer func() { endSpan(span, err) }()
 	if processID == "" {
 		return errors.New("processID cannot be empty")
 	}
@@ -231,12 +312,12 @@ func (dsdk *DataPlaneSDK) Suspend(ctx context.Context, processID string, reason
 		if err := dsdk.onSuspend(ctx, flow); err != nil {
 			return fmt.Errorf("suspending data flow %s: %w", flow.ID, err)
 		}
-		err = flow.TransitionToSuspended(reason)
+		err = flow.suspend(failure)
 		if err != nil {
 			return err
 		}
 
-		err = dsdk.Store.Save(ctx, flow)
+		err = dsdk.save(ctx, flow)
 		if err != nil {
 			return fmt.Errorf("suspending data flow %s: %w", flow.ID, err)
 		}
@@ -245,20 +326,126 @@ func (dsdk *DataPlaneSDK) Suspend(ctx context.Context, processID string, reason
 
 }
 
-func (dsdk *DataPlaneSDK) Status(ctx context.Context, id string) (*DataFlow, error) {
+// Complete is called when a finite transfer has finished. It invokes the onComplete callback and transitions the flow
+// to COMPLETED.
+func (dsdk *DataPlaneSDK) Complete(ctx context.Context, processID string) (err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.Complete", AttributeFlowID.String(processID))
+	defer func() { endSpan(span, err) }()
+	if processID == "" {
+		return errors.New("processID cannot be empty")
+	}
+
+	return dsdk.execute(ctx, func(ctx context.Context) error {
+		flow, err := dsdk.Store.FindById(ctx, processID)
+		if err != nil {
+			return fmt.Errorf("completing data flow %s: %w", processID, err)
+		}
+
+		if Completed == flow.State {
+			return nil // duplicate message, skip processing
+		}
+
+		if err := dsdk.onComplete(ctx, flow); err != nil {
+			return fmt.Errorf("completing data flow %s: %w", flow.ID, err)
+		}
+
+		err = flow.TransitionToCompleted()
+		if err != nil {
+			return err
+		}
+
+		err = dsdk.save(ctx, flow)
+		if err != nil {
+			return fmt.Errorf("completing data flow %s: %w", flow.ID, err)
+		}
+		return nil
+	})
+}
+
+// Status returns the flow with the given id. The flow is read outside a transaction, so stores that lock flows loaded
+// in a transaction, such as the Postgres store, do not block concurrent messages for the flow.
+func (dsdk *DataPlaneSDK) Status(ctx context.Context, id string) (_ *DataFlow, err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.Status", AttributeFlowID.String(id))
+	defer func() { endSpan(span, err) }()
+	flow, err := dsdk.Store.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	recordFlow(ctx, flow, flow.State)
+	return flow, nil
+}
+
+// NotifyPrepared transitions a PREPARING flow to PREPARED outside the signaling request path and reports the change to
+// the control plane. It is used by processors that returned PREPARING to complete preparation asynchronously.
+func (dsdk *DataPlaneSDK) NotifyPrepared(ctx context.Context, processID string, dataAddress *DataAddress) error {
+	return dsdk.notifyTransition(ctx, "DataPlaneSDK.NotifyPrepared", processID, dataAddress, func(flow *DataFlow) error {
+		return flow.TransitionToPrepared()
+	})
+}
+
+// NotifyStarted transitions a STARTING flow to STARTED outside the signaling request path and reports the change to
+// the control plane. It is used by processors that returned STARTING to complete the start asynchronously.
+func (dsdk *DataPlaneSDK) NotifyStarted(ctx context.Context, processID string, dataAddress *DataAddress) error {
+	return dsdk.notifyTransition(ctx, "DataPlaneSDK.NotifyStarted", processID, dataAddress, func(flow *DataFlow) error {
+		return flow.TransitionToStarted()
+	})
+}
+
+// NotifyCompleted transitions a STARTED flow to COMPLETED and reports the change to the control plane.
+func (dsdk *DataPlaneSDK) NotifyCompleted(ctx context.Context, processID string) error {
+	return dsdk.notifyTransition(ctx, "DataPlaneSDK.NotifyCompleted", processID, nil, func(flow *DataFlow) error {
+		return flow.TransitionToCompleted()
+	})
+}
+
+// NotifyFailed terminates a flow that failed in the data plane and reports the reason to the control plane. The reason
+// is recorded as the message of a failure with FailureCodeTransfer.
+func (dsdk *DataPlaneSDK) NotifyFailed(ctx context.Context, processID string, reason string) error {
+	return dsdk.NotifyFailure(ctx, processID, Failure{Code: FailureCodeTransfer, Message: reason})
+}
+
+// NotifyFailure terminates a flow that failed in the data plane, records the failure and reports it to the control
+// plane. The origin of the failure defaults to FailureOriginLocal.
+func (dsdk *DataPlaneSDK) NotifyFailure(ctx context.Context, processID string, failure Failure) error {
+	return dsdk.notifyTransition(ctx, "DataPlaneSDK.NotifyFailed", processID, nil, func(flow *DataFlow) error {
+		return flow.fail(failure)
+	})
+}
+
+// notifyTransition applies the transition to the stored flow in a transaction and notifies the control plane after the
+// transaction has completed. If the notification fails, the new state remains persisted and the error is returned.
+func (dsdk *DataPlaneSDK) notifyTransition(ctx context.Context, operation string, processID string, dataAddress *DataAddress, transition func(*DataFlow) error) (err error) {
+	ctx, span := dsdk.startSpan(ctx, operation, AttributeFlowID.String(processID))
+	defer func() { endSpan(span, err) }()
+	if processID == "" {
+		return errors.New("processID cannot be empty")
+	}
+
 	var flow *DataFlow
-	err := dsdk.execute(ctx, func(ctx context.Context) error {
-		found, err := dsdk.Store.FindById(ctx, id)
+	err = dsdk.execute(ctx, func(ctx context.Context) error {
+		found, err := dsdk.Store.FindById(ctx, processID)
 		if err != nil {
+			return fmt.Errorf("updating data flow %s: %w", processID, err)
+		}
+
+		if err := transition(found); err != nil {
 			return err
 		}
+
+		if err := dsdk.save(ctx, found); err != nil {
+			return fmt.Errorf("updating data flow %s: %w", processID, err)
+		}
 		flow = found
-		return nil
+		return dsdk.enqueueNotification(ctx, found, dataAddress)
 	})
-	return flow, err
+	if err != nil {
+		return err
+	}
+	return dsdk.notify(ctx, flow, dataAddress)
 }
 
-func (dsdk *DataPlaneSDK) startExistingFlow(ctx context.Context, flow *DataFlow, sourceAddress *DataAddress) (*DataFlowResponseMessage, error) {
+func (dsdk *DataPlaneSDK) startExistingFlow(ctx context.Context, flow *DataFlow, sourceAddress *DataAddress, destinationAddress *DataAddress) (*DataFlowResponseMessage, error) {
+	updateAddresses(flow, sourceAddress, destinationAddress)
 	switch {
 	case flow != nil && (flow.State == Starting || flow.State == Started):
 		// duplicate message, pass to handler to generate a data address if needed
@@ -272,7 +459,7 @@ func (dsdk *DataPlaneSDK) startExistingFlow(ctx context.Context, flow *DataFlow,
 			return nil, fmt.Errorf("onStart returned an invalid state: %w", err)
 		}
 
-		if err := dsdk.Store.Save(ctx, flow); err != nil {
+		if err := dsdk.save(ctx, flow); err != nil {
 			return nil, fmt.Errorf("creating data flow: %w", err)
 		}
 		return response, err
@@ -288,7 +475,28 @@ func (dsdk *DataPlaneSDK) startExistingFlow(ctx context.Context, flow *DataFlow,
 			return nil, fmt.Errorf("onStart returned an invalid state: %w", err)
 		}
 
-		if err := dsdk.Store.Save(ctx, flow); err != nil {
+		if err := dsdk.save(ctx, flow); err != nil {
+			return nil, fmt.Errorf("updating data flow: %w", err)
+		}
+
+		return response, nil
+	case flow != nil && flow.State == Suspended:
+		// resume a suspended flow
+		response, err := dsdk.onResume(ctx, flow, dsdk, &ProcessorOptions{SourceDataAddress: sourceAddress, SuspensionReason: flow.ErrorDetail})
+		if err != nil {
+			return nil, fmt.Errorf("resuming data flow: %w", err)
+		}
+
+		if response.State != Started {
+			return nil, fmt.Errorf("onResume returned an invalid state %s", response.State)
+		}
+		if err := flow.TransitionToStarted(); err != nil {
+			return nil, err
+		}
+		flow.ErrorDetail = ""
+		flow.Failure = nil
+
+		if err := dsdk.save(ctx, flow); err != nil {
 			return nil, fmt.Errorf("updating data flow: %w", err)
 		}
 
@@ -299,6 +507,17 @@ func (dsdk *DataPlaneSDK) startExistingFlow(ctx context.Context, flow *DataFlow,
 	}
 }
 
+// updateAddresses stores the data addresses of a signaling message on the flow. Empty addresses do not overwrite
+// addresses received with earlier messages.
+func updateAddresses(flow *DataFlow, source *DataAddress, destination *DataAddress) {
+	if !source.IsEmpty() {
+		flow.SourceDataAddress = *source
+	}
+	if !destination.IsEmpty() {
+		flow.DestinationDataAddress = *destination
+	}
+}
+
 func (dsdk *DataPlaneSDK) startState(response *DataFlowResponseMessage, flow *DataFlow) error {
 	if response.State == Started {
 		err := flow.TransitionToStarted()
@@ -316,17 +535,32 @@ func (dsdk *DataPlaneSDK) startState(response *DataFlowResponseMessage, flow *Da
 	return nil
 }
 
+// execute runs the callback in a transaction. If listeners are subscribed, the events of state changes persisted by
+// the callback are published after the transaction has committed.
 func (dsdk *DataPlaneSDK) execute(ctx context.Context, callback func(ctx2 context.Context) error) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
+	}
+	if _, nested := ctx.Value(eventBufferKey{}).(*eventBuffer); nested || !dsdk.events.hasSubscriptions() {
 		return dsdk.TrxContext.Execute(ctx, callback)
 	}
+	buffer := &eventBuffer{}
+	if err := dsdk.TrxContext.Execute(context.WithValue(ctx, eventBufferKey{}, buffer), callback); err != nil {
+		return err
+	}
+	dsdk.events.publish(ctx, buffer.events)
+	return nil
 }
 
 type DataPlaneSDKBuilder struct {
-	sdk *DataPlaneSDK
+	sdk            *DataPlaneSDK
+	interceptors   []Interceptor
+	tracerProvider trace.TracerProvider
+	metrics        *Metrics
+	logHandler     slog.Handler
+	built          bool
 }
 
 func NewDataPlaneSDKBuilder() *DataPlaneSDKBuilder {
@@ -345,6 +579,97 @@ func (b *DataPlaneSDKBuilder) TransactionContext(trxContext TransactionContext)
 	return b
 }
 
+func (b *DataPlaneSDKBuilder) ControlPlaneNotifier(notifier ControlPlaneNotifier) *DataPlaneSDKBuilder {
+	b.sdk.Notifier = notifier
+	return b
+}
+
+func (b *DataPlaneSDKBuilder) RuntimeID(id string) *DataPlaneSDKBuilder {
+	b.sdk.RuntimeID = id
+	return b
+}
+
+// LeaseDuration enables leasing of flows to this runtime. Requires a runtime ID. See MaintainLeases.
+func (b *DataPlaneSDKBuilder) LeaseDuration(duration time.Duration) *DataPlaneSDKBuilder {
+	b.sdk.leaseDuration = duration
+	return b
+}
+
+// StateTimeout enables the watchdog for flows in PREPARING or STARTING. See ReapStuckFlows.
+func (b *DataPlaneSDKBuilder) StateTimeout(state DataFlowState, timeout StateTimeout) *DataPlaneSDKBuilder {
+	if b.sdk.stateTimeouts == nil {
+		b.sdk.stateTimeouts = make(map[DataFlowState]StateTimeout)
+	}
+	b.sdk.stateTimeouts[state] = timeout
+	return b
+}
+
+// RetentionPolicy enables the removal of finished flows. See PurgeFlows.
+func (b *DataPlaneSDKBuilder) RetentionPolicy(policy RetentionPolicy) *DataPlaneSDKBuilder {
+	b.sdk.retention = &policy
+	return b
+}
+
+// OnArchive registers a hook that receives each flow before it is removed by PurgeFlows.
+func (b *DataPlaneSDKBuilder) OnArchive(archiver FlowArchiver) *DataPlaneSDKBuilder {
+	b.sdk.archiver = archiver
+	return b
+}
+
+func (b *DataPlaneSDKBuilder) IdempotencyStore(store IdempotencyStore) *DataPlaneSDKBuilder {
+	b.sdk.IdempotencyStore = store
+	return b
+}
+
+// IdempotencyRetention sets how long replayed messages are answered with their original response. Defaults to
+// DefaultIdempotencyRetention.
+func (b *DataPlaneSDKBuilder) IdempotencyRetention(retention time.Duration) *DataPlaneSDKBuilder {
+	b.sdk.idempotencyRetention = retention
+	return b
+}
+
+func (b *DataPlaneSDKBuilder) OutboxStore(store OutboxStore) *DataPlaneSDKBuilder {
+	b.sdk.OutboxStore = store
+	return b
+}
+
+// OutboxRetryPolicy sets the backoff between failed deliveries of an outbox entry and the number of deliveries before the
+// entry is dropped. Defaults to DefaultOutboxRetryPolicy. A CallbackClient notifier makes a single attempt per delivery;
+// custom notifiers used with an outbox should not retry themselves.
+func (b *DataPlaneSDKBuilder) OutboxRetryPolicy(policy RetryPolicy) *DataPlaneSDKBuilder {
+	b.sdk.outboxRetry = policy
+	return b
+}
+
+// Interceptor registers interceptors that wrap all processors and handlers, including the defaults. Interceptors run in
+// the order they are registered, the first one outermost.
+func (b *DataPlaneSDKBuilder) Interceptor(interceptors ...Interceptor) *DataPlaneSDKBuilder {
+	b.interceptors = append(b.interceptors, interceptors...)
+	return b
+}
+
+// TracerProvider enables tracing. The SDK records spans for its operations, processor and handler invocations, and
+// store methods; the Store of the built SDK is wrapped for this purpose. Tracing is disabled by default.
+func (b *DataPlaneSDKBuilder) TracerProvider(provider trace.TracerProvider) *DataPlaneSDKBuilder {
+	b.tracerProvider = provider
+	return b
+}
+
+// Metrics enables Prometheus metrics for flows, state transitions, processor and handler invocations, store methods,
+// and signaling requests. The Store of the built SDK is wrapped for this purpose. Metrics are disabled by default.
+func (b *DataPlaneSDKBuilder) Metrics(metrics *Metrics) *DataPlaneSDKBuilder {
+	b.metrics = metrics
+	return b
+}
+
+// LogHandler sets the handler of the SDK logger. It is wrapped with NewLogHandler, so records carry the flow ID, message
+// ID, operation, participant and counterparty of the context and secrets are redacted. Defaults to the handler of
+// slog.Default().
+func (b *DataPlaneSDKBuilder) LogHandler(handler slog.Handler) *DataPlaneSDKBuilder {
+	b.logHandler = handler
+	return b
+}
+
 func (b *DataPlaneSDKBuilder) OnPrepare(processor DataFlowProcessor) *DataPlaneSDKBuilder {
 	b.sdk.onPrepare = processor
 	return b
@@ -355,6 +680,20 @@ func (b *DataPlaneSDKBuilder) OnStart(processor DataFlowProcessor) *DataPlaneSDK
 	return b
 }
 
+// OnResume registers the processor invoked when a suspended flow is started again. The processor receives the new source
+// data address and the suspension reason and must return STARTED.
+func (b *DataPlaneSDKBuilder) OnResume(processor DataFlowProcessor) *DataPlaneSDKBuilder {
+	b.sdk.onResume = processor
+	return b
+}
+
+// OnRecover registers the processor that is invoked for each non-terminal flow when Recover is called after a restart.
+// The returned state is applied to the flow; returning the current state leaves it unchanged.
+func (b *DataPlaneSDKBuilder) OnRecover(processor DataFlowProcessor) *DataPlaneSDKBuilder {
+	b.sdk.onRecover = processor
+	return b
+}
+
 func (b *DataPlaneSDKBuilder) OnTerminate(handler DataFlowHandler) *DataPlaneSDKBuilder {
 	b.sdk.onTerminate = handler
 	return b
@@ -365,6 +704,11 @@ func (b *DataPlaneSDKBuilder) OnSuspend(handler DataFlowHandler) *DataPlaneSDKBu
 	return b
 }
 
+func (b *DataPlaneSDKBuilder) OnComplete(handler DataFlowHandler) *DataPlaneSDKBuilder {
+	b.sdk.onComplete = handler
+	return b
+}
+
 func (b *DataPlaneSDKBuilder) Build() (*DataPlaneSDK, error) {
 	if b.sdk.Store == nil {
 		return nil, errors.New("store is required")
@@ -372,6 +716,34 @@ func (b *DataPlaneSDKBuilder) Build() (*DataPlaneSDK, error) {
 	if b.sdk.TrxContext == nil {
 		return nil, errors.New("transaction context is required")
 	}
+	if b.sdk.leaseDuration > 0 && b.sdk.RuntimeID == "" {
+		return nil, errors.New("runtime ID is required for leasing")
+	}
+	for state, timeout := range b.sdk.stateTimeouts {
+		if !slices.Contains(watchableStates, state) {
+			return nil, fmt.Errorf("state timeouts are not supported for %s", state)
+		}
+		if timeout.Timeout <= 0 {
+			return nil, fmt.Errorf("state timeout for %s must be positive", state)
+		}
+	}
+	if policy := b.sdk.retention; policy != nil {
+		if policy.MaxAge <= 0 && policy.KeepPerAgreement <= 0 {
+			return nil, errors.New("retention policy requires a maximum age or a number of flows to keep per agreement")
+		}
+		for _, state := range policy.States {
+			if state != Completed && state != Terminated {
+				return nil, fmt.Errorf("retention policy cannot remove flows in state %s", state)
+			}
+		}
+	}
+	if b.sdk.outboxRetry == (RetryPolicy{}) {
+		b.sdk.outboxRetry = DefaultOutboxRetryPolicy()
+	} else if b.sdk.outboxRetry.InitialBackoff <= 0 {
+		return nil, errors.New("outbox retry policy requires a positive initial backoff")
+	} else if b.sdk.outboxRetry.MaxAttempts < 1 {
+		return nil, errors.New("outbox retry policy requires at least one attempt")
+	}
 	if b.sdk.onPrepare == nil {
 		b.sdk.onPrepare = func(context context.Context, flow *DataFlow, sdk *DataPlaneSDK, options *ProcessorOptions) (*DataFlowResponseMessage, error) {
 			return &DataFlowResponseMessage{
@@ -390,6 +762,20 @@ func (b *DataPlaneSDKBuilder) Build() (*DataPlaneSDK, error) {
 				Error:       ""}, nil
 		}
 	}
+	if b.sdk.onResume == nil {
+		b.sdk.onResume = func(context context.Context, flow *DataFlow, sdk *DataPlaneSDK, options *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{
+				State:       Started,
+				DataplaneID: "TODO_REPLACE_ME",
+				DataAddress: &flow.DestinationDataAddress,
+				Error:       ""}, nil
+		}
+	}
+	if b.sdk.onRecover == nil {
+		b.sdk.onRecover = func(context context.Context, flow *DataFlow, sdk *DataPlaneSDK, options *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: flow.State}, nil
+		}
+	}
 	if b.sdk.onTerminate == nil {
 		b.sdk.onTerminate = func(context context.Context, flow *DataFlow) error {
 			return nil
@@ -400,19 +786,50 @@ func (b *DataPlaneSDKBuilder) Build() (*DataPlaneSDK, error) {
 			return nil
 		}
 	}
-	if b.sdk.Monitor == nil {
-		b.sdk.Monitor = defaultLogMonitor{}
+	if b.sdk.onComplete == nil {
+		b.sdk.onComplete = func(context context.Context, flow *DataFlow) error {
+			return nil
+		}
+	}
+	if b.sdk.idempotencyRetention <= 0 {
+		b.sdk.idempotencyRetention = DefaultIdempotencyRetention
+	}
+	if b.sdk.Logger == nil {
+		b.sdk.Logger = newLogger(b.logHandler)
+	}
+	if b.sdk.Notifier == nil {
+		notifier, err := NewCallbackClientBuilder().Build()
+		if err != nil {
+			return nil, err
+		}
+		b.sdk.Notifier = notifier
+	}
+	var interceptors []Interceptor
+	if !b.built {
+		interceptors = append([]Interceptor{logContextInterceptor}, b.interceptors...)
+	}
+	if b.metrics != nil && b.sdk.metrics == nil {
+		if err := b.metrics.registry.Register(newFlowCollector(b.sdk.Store)); err != nil {
+			return nil, fmt.Errorf("registering data flow metrics: %w", err)
+		}
+		b.sdk.metrics = b.metrics
+		b.sdk.Subscribe(b.metrics.countTransition, Synchronous)
+		interceptors = append(interceptors, b.metrics.interceptor())
+	}
+	if b.tracerProvider != nil && b.sdk.tracer == nil {
+		b.sdk.tracer = b.tracerProvider.Tracer(instrumentationName)
+		interceptors = append(interceptors, tracingInterceptor(b.sdk.tracer))
+	}
+	if _, instrumented := b.sdk.Store.(*instrumentedStore); !instrumented && (b.sdk.tracer != nil || b.sdk.metrics != nil) {
+		b.sdk.Store = &instrumentedStore{store: b.sdk.Store, tracer: b.sdk.tracer, metrics: b.sdk.metrics}
 	}
+	b.sdk.onPrepare = interceptProcessor(OperationPrepare, b.sdk.onPrepare, interceptors)
+	b.sdk.onStart = interceptProcessor(OperationStart, b.sdk.onStart, interceptors)
+	b.sdk.onResume = interceptProcessor(OperationResume, b.sdk.onResume, interceptors)
+	b.sdk.onRecover = interceptProcessor(OperationRecover, b.sdk.onRecover, interceptors)
+	b.sdk.onTerminate = interceptHandler(OperationTerminate, b.sdk.onTerminate, interceptors)
+	b.sdk.onSuspend = interceptHandler(OperationSuspend, b.sdk.onSuspend, interceptors)
+	b.sdk.onComplete = interceptHandler(OperationComplete, b.sdk.onComplete, interceptors)
+	b.built = true // wrap only once if Build is called again
 	return b.sdk, nil
 }
-
-type defaultLogMonitor struct {
-}
-
-func (d defaultLogMonitor) Println(v ...any) {
-	log.Println(v...)
-}
-
-func (d defaultLogMonitor) Printf(format string, v ...any) {
-	log.Printf(format, v...)
-}
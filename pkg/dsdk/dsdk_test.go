@@ -212,6 +212,161 @@ func Test_DataPlaneSDK_Start_ConsumerPrepared(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_DataPlaneSDK_Start_StoresDataAddresses(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onStart: func(_ context.Context, flow *DataFlow, _ *DataPlaneSDK, _ *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			assert.Equal(t, "source", flow.SourceDataAddress.Properties["name"])
+			assert.Equal(t, "destination", flow.DestinationDataAddress.Properties["name"])
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "process123").Return(nil, ErrNotFound)
+	store.EXPECT().Create(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.SourceDataAddress.Properties["name"] == "source" &&
+			df.DestinationDataAddress.Properties["name"] == "destination"
+	})).Return(nil)
+
+	message := createStartMessage()
+	message.SourceDataAddress = &DataAddress{Properties: map[string]any{"name": "source"}}
+	message.DestinationDataAddress = DataAddress{Properties: map[string]any{"name": "destination"}}
+
+	_, err := dsdk.Start(ctx, message)
+	assert.NoError(t, err)
+}
+
+func Test_DataPlaneSDK_Start_DuplicateUpdatesDataAddresses(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onStart: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "process123").Return(&DataFlow{
+		ID:                     "process123",
+		State:                  Started,
+		SourceDataAddress:      DataAddress{Properties: map[string]any{"name": "old-source"}},
+		DestinationDataAddress: DataAddress{Properties: map[string]any{"name": "old-destination"}},
+	}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		// the empty destination of the duplicate must not overwrite the stored one
+		return df.SourceDataAddress.Properties["name"] == "new-source" &&
+			df.DestinationDataAddress.Properties["name"] == "old-destination"
+	})).Return(nil)
+
+	message := createStartMessage()
+	message.SourceDataAddress = &DataAddress{Properties: map[string]any{"name": "new-source"}}
+
+	_, err := dsdk.Start(ctx, message)
+	assert.NoError(t, err)
+}
+
+func Test_DataPlaneSDK_StartById_UpdatesSourceDataAddress(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onStart: func(_ context.Context, flow *DataFlow, _ *DataPlaneSDK, _ *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			assert.Equal(t, "source", flow.SourceDataAddress.Properties["name"])
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{ID: "flow123", State: Prepared, Consumer: true}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.SourceDataAddress.Properties["name"] == "source"
+	})).Return(nil)
+
+	_, err := dsdk.StartById(ctx, "flow123", DataFlowStartByIdMessage{
+		SourceDataAddress: &DataAddress{Properties: map[string]any{"name": "source"}},
+	})
+	assert.NoError(t, err)
+}
+
+func Test_DataPlaneSDK_StartById_ResumeSuspended(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	source := &DataAddress{Properties: map[string]any{"foo": "bar"}}
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onStart: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			t.Fatal("onStart must not be invoked when resuming")
+			return nil, nil
+		},
+		onResume: func(ctx context.Context, flow *DataFlow, sdk *DataPlaneSDK, opts *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			assert.Equal(t, "maintenance", opts.SuspensionReason)
+			assert.Equal(t, source, opts.SourceDataAddress)
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:          "flow123",
+		State:       Suspended,
+		ErrorDetail: "maintenance",
+	}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.State == Started && df.ErrorDetail == ""
+	})).Return(nil)
+
+	response, err := dsdk.StartById(ctx, "flow123", DataFlowStartByIdMessage{SourceDataAddress: source})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Started, response.State)
+}
+
+func Test_DataPlaneSDK_StartById_ResumeInvalidState(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onResume: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: Starting}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Suspended,
+	}, nil)
+
+	_, err := dsdk.StartById(ctx, "flow123", DataFlowStartByIdMessage{SourceDataAddress: &DataAddress{}})
+
+	assert.ErrorContains(t, err, "onResume returned an invalid state")
+}
+
+func Test_DataPlaneSDK_StartById_ResumeError(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onResume: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return nil, errors.New("some error")
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Suspended,
+	}, nil)
+
+	_, err := dsdk.StartById(ctx, "flow123", DataFlowStartByIdMessage{SourceDataAddress: &DataAddress{}})
+
+	assert.ErrorContains(t, err, "some error")
+}
+
 func Test_DataPlaneSDK_Status(t *testing.T) {
 	store := NewMockDataplaneStore(t)
 	dsdk := DataPlaneSDK{
@@ -245,6 +400,23 @@ func Test_DataPlaneSDK_Status_WhenNotFound(t *testing.T) {
 	assert.Nil(t, flow)
 }
 
+func Test_DataPlaneSDK_ReadsWithoutTransaction(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &failingTrxContext{t: t},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "process123").Return(&DataFlow{ID: "process123", State: Started}, nil)
+	store.EXPECT().History(ctx, "process123").Return([]StateTransition{{ProcessID: "process123", ToState: Started}}, nil)
+
+	_, err := dsdk.Status(ctx, "process123")
+	assert.NoError(t, err)
+	_, err = dsdk.History(ctx, "process123")
+	assert.NoError(t, err)
+}
+
 func Test_DataPlaneSDK_Prepare(t *testing.T) {
 	store := NewMockDataplaneStore(t)
 	dsdk := DataPlaneSDK{
@@ -265,6 +437,29 @@ func Test_DataPlaneSDK_Prepare(t *testing.T) {
 	assert.NotNil(t, response)
 }
 
+func Test_DataPlaneSDK_Prepare_StoresDestinationDataAddress(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onPrepare: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: Prepared}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "process123").Return(nil, ErrNotFound)
+	store.EXPECT().Create(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.DestinationDataAddress.Properties["name"] == "destination"
+	})).Return(nil)
+
+	message := createPrepareMessage()
+	message.DestinationDataAddress = DataAddress{Properties: map[string]any{"name": "destination"}}
+
+	_, err := dsdk.Prepare(ctx, message)
+	assert.NoError(t, err)
+}
+
 func Test_DataPlaneSDK_Prepare_VerifySdkCallback(t *testing.T) {
 	tests := []struct {
 		state       DataFlowState
@@ -567,6 +762,239 @@ func Test_DataPlaneSDK_Suspend_SdkCallbackError(t *testing.T) {
 	assert.ErrorContains(t, err, "some error")
 }
 
+func Test_DataPlaneSDK_Complete(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onComplete: func(ctx context.Context, flow *DataFlow) error {
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Started,
+	}, nil)
+
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.State == Completed
+	})).Return(nil)
+
+	err := dsdk.Complete(ctx, "flow123")
+
+	assert.NoError(t, err)
+}
+
+func Test_DataPlaneSDK_Complete_NotFound(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onComplete: func(ctx context.Context, flow *DataFlow) error {
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+
+	store.EXPECT().FindById(ctx, "flow123").Return(nil, ErrNotFound)
+	err := dsdk.Complete(ctx, "flow123")
+
+	assert.ErrorContains(t, err, "not found")
+}
+
+func Test_DataPlaneSDK_Complete_AlreadyCompleted(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onComplete: func(ctx context.Context, flow *DataFlow) error {
+			t.Fatal("onComplete must not be invoked for duplicates")
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Completed, // already completed
+	}, nil)
+
+	// no transition and no save call expected
+
+	err := dsdk.Complete(ctx, "flow123")
+
+	assert.NoError(t, err)
+}
+
+func Test_DataPlaneSDK_Complete_InvalidTransition(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onComplete: func(ctx context.Context, flow *DataFlow) error {
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Prepared,
+	}, nil)
+
+	err := dsdk.Complete(ctx, "flow123")
+
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func Test_DataPlaneSDK_Complete_SdkCallbackError(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onComplete: func(ctx context.Context, flow *DataFlow) error {
+			return fmt.Errorf("some error")
+		},
+	}
+
+	ctx := context.Background()
+
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Started,
+	}, nil)
+
+	err := dsdk.Complete(ctx, "flow123")
+
+	assert.ErrorContains(t, err, "some error")
+}
+
+func Test_DataPlaneSDK_NotifyStarted(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		Notifier:   notifier,
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Starting,
+	}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.State == Started
+	})).Return(nil)
+
+	address := &DataAddress{Properties: map[string]any{"foo": "bar"}}
+	err := dsdk.NotifyStarted(ctx, "flow123", address)
+
+	assert.NoError(t, err)
+	assert.Len(t, notifier.flows, 1)
+	assert.Equal(t, Started, notifier.flows[0].State)
+	assert.Equal(t, address, notifier.addresses[0])
+}
+
+func Test_DataPlaneSDK_NotifyPrepared(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		Notifier:   notifier,
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:       "flow123",
+		State:    Preparing,
+		Consumer: true,
+	}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.State == Prepared
+	})).Return(nil)
+
+	err := dsdk.NotifyPrepared(ctx, "flow123", &DataAddress{})
+
+	assert.NoError(t, err)
+	assert.Len(t, notifier.flows, 1)
+	assert.Equal(t, Prepared, notifier.flows[0].State)
+}
+
+func Test_DataPlaneSDK_NotifyFailed(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		Notifier:   notifier,
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Starting,
+	}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.State == Terminated && df.ErrorDetail == "provisioning failed"
+	})).Return(nil)
+
+	err := dsdk.NotifyFailed(ctx, "flow123", "provisioning failed")
+
+	assert.NoError(t, err)
+	assert.Len(t, notifier.flows, 1)
+	assert.Equal(t, "provisioning failed", notifier.flows[0].ErrorDetail)
+	assert.Equal(t, FailureCodeTransfer, notifier.flows[0].Failure.Code)
+	assert.Equal(t, FailureOriginLocal, notifier.flows[0].Failure.Origin)
+	assert.False(t, notifier.flows[0].Failure.Retryable)
+}
+
+func Test_DataPlaneSDK_NotifyStarted_InvalidTransition(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		Notifier:   notifier,
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:    "flow123",
+		State: Terminated,
+	}, nil)
+
+	err := dsdk.NotifyStarted(ctx, "flow123", nil)
+
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Empty(t, notifier.flows)
+}
+
+func Test_DataPlaneSDK_NotifyStarted_NotFound(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		Notifier:   notifier,
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(nil, ErrNotFound)
+
+	err := dsdk.NotifyStarted(ctx, "flow123", nil)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Empty(t, notifier.flows)
+}
+
 func createPrepareMessage() DataFlowPrepareMessage {
 	return DataFlowPrepareMessage{DataFlowBaseMessage: createBaseMessage()}
 }
@@ -600,3 +1028,24 @@ type mockTrxContext struct {
 func (c *mockTrxContext) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
 	return fn(ctx)
 }
+
+// failingTrxContext fails the test when a transaction is opened.
+type failingTrxContext struct {
+	t *testing.T
+}
+
+func (c *failingTrxContext) Execute(context.Context, func(ctx context.Context) error) error {
+	c.t.Error("unexpected transaction")
+	return errors.New("unexpected transaction")
+}
+
+type mockNotifier struct {
+	flows     []DataFlow
+	addresses []*DataAddress
+}
+
+func (n *mockNotifier) Notify(_ context.Context, flow *DataFlow, dataAddress *DataAddress) error {
+	n.flows = append(n.flows, *flow)
+	n.addresses = append(n.addresses, dataAddress)
+	return nil
+}
@@ -17,6 +17,10 @@ var (
 	ErrInvalidInput = errors.New("invalid input")
 	// ErrInvalidTransition Sentinel error to indicate an invalid state transition, e.g. of a data flow
 	ErrInvalidTransition = errors.New("invalid transition")
+	// ErrUnauthorized Sentinel error to indicate that a caller could not be authenticated
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrProcessorPanic Sentinel error to indicate that a processor or handler panicked, see RecoveryInterceptor
+	ErrProcessorPanic = errors.New("processor panicked")
 )
 
 // NewValidationError Helper to create new ValidationError
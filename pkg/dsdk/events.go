@@ -0,0 +1,241 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// EventType identifies a data flow lifecycle event.
+type EventType string
+
+const (
+	EventPrepared  EventType = "Prepared"
+	EventStarted   EventType = "Started"
+	EventSuspended EventType = "Suspended"
+	EventCompleted EventType = "Completed"
+	// EventTerminated is published when a flow is terminated on request, e.g. by the control plane.
+	EventTerminated EventType = "Terminated"
+	// EventFailed is published when the data plane terminates a flow, e.g. through NotifyFailed, a processor returning
+	// TERMINATED or the watchdog.
+	EventFailed EventType = "Failed"
+)
+
+// Event reports a state change of a data flow.
+type Event struct {
+	Type       EventType
+	Transition StateTransition
+	// Flow is a snapshot of the flow after the transition. Listeners must not modify it.
+	Flow DataFlow
+}
+
+// EventListener receives lifecycle events.
+type EventListener func(ctx context.Context, event Event)
+
+// DeliveryMode defines how events are passed to a listener.
+type DeliveryMode int
+
+const (
+	// Synchronous listeners are invoked by the goroutine that committed the transaction, before the SDK operation returns.
+	Synchronous DeliveryMode = iota
+	// Asynchronous listeners are invoked in order by a dedicated goroutine. If its queue is full, publishing blocks.
+	Asynchronous
+)
+
+// asyncQueueSize is the number of events buffered for an asynchronous listener.
+const asyncQueueSize = 1024
+
+// Subscribe registers a listener for the given event types, or for all types if none are given. Events are delivered
+// only after the transaction that persisted the state change has committed. The returned function removes the
+// subscription; for asynchronous listeners, it waits until queued events have been delivered.
+func (dsdk *DataPlaneSDK) Subscribe(listener EventListener, mode DeliveryMode, types ...EventType) func() {
+	return dsdk.events.subscribe(listener, mode, types, dsdk.logger())
+}
+
+// save persists the flow, records it on the current span and collects events for its pending transitions.
+func (dsdk *DataPlaneSDK) save(ctx context.Context, flow *DataFlow) error {
+	transitions := slices.Clone(flow.PendingTransitions())
+	recordFlow(ctx, flow, stateBefore(flow))
+	if err := dsdk.Store.Save(ctx, flow); err != nil {
+		return err
+	}
+	collectEvents(ctx, flow, transitions)
+	return nil
+}
+
+// create persists a new flow, records it on the current span and collects events for its pending transitions.
+func (dsdk *DataPlaneSDK) create(ctx context.Context, flow *DataFlow) error {
+	transitions := slices.Clone(flow.PendingTransitions())
+	recordFlow(ctx, flow, stateBefore(flow))
+	if err := dsdk.Store.Create(ctx, flow); err != nil {
+		return err
+	}
+	collectEvents(ctx, flow, transitions)
+	return nil
+}
+
+type eventBufferKey struct{}
+
+// eventBuffer collects the events of a transaction until it has committed.
+type eventBuffer struct {
+	events []Event
+}
+
+func collectEvents(ctx context.Context, flow *DataFlow, transitions []StateTransition) {
+	buffer, ok := ctx.Value(eventBufferKey{}).(*eventBuffer)
+	if !ok {
+		return
+	}
+	snapshot := *flow
+	snapshot.transitions = nil
+	for _, transition := range transitions {
+		if eventType, ok := eventTypeOf(transition); ok {
+			transition.ProcessID = flow.ID
+			buffer.events = append(buffer.events, Event{Type: eventType, Transition: transition, Flow: snapshot})
+		}
+	}
+}
+
+func eventTypeOf(transition StateTransition) (EventType, bool) {
+	switch transition.ToState {
+	case Prepared:
+		return EventPrepared, true
+	case Started:
+		return EventStarted, true
+	case Suspended:
+		return EventSuspended, true
+	case Completed:
+		return EventCompleted, true
+	case Terminated:
+		if transition.failure {
+			return EventFailed, true
+		}
+		return EventTerminated, true
+	default:
+		return "", false
+	}
+}
+
+// eventBus dispatches events to subscriptions. The zero value is ready to use.
+type eventBus struct {
+	mu            sync.RWMutex
+	subscriptions []*subscription
+}
+
+type subscription struct {
+	listener EventListener
+	types    []EventType
+	logger   *slog.Logger
+
+	// asynchronous delivery; queue is nil for synchronous listeners
+	mu     sync.Mutex
+	closed bool
+	queue  chan queuedEvent
+	done   chan struct{}
+}
+
+type queuedEvent struct {
+	ctx   context.Context
+	event Event
+}
+
+func (b *eventBus) subscribe(listener EventListener, mode DeliveryMode, types []EventType, logger *slog.Logger) func() {
+	s := &subscription{listener: listener, types: slices.Clone(types), logger: logger}
+	if mode == Asynchronous {
+		s.queue = make(chan queuedEvent, asyncQueueSize)
+		s.done = make(chan struct{})
+		go s.run()
+	}
+
+	b.mu.Lock()
+	b.subscriptions = append(b.subscriptions, s)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			b.subscriptions = slices.DeleteFunc(b.subscriptions, func(other *subscription) bool { return other == s })
+			b.mu.Unlock()
+			s.close()
+		})
+	}
+}
+
+func (b *eventBus) hasSubscriptions() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscriptions) > 0
+}
+
+// publish delivers committed events. The bus is not locked during delivery, so listeners may use the SDK.
+func (b *eventBus) publish(ctx context.Context, events []Event) {
+	b.mu.RLock()
+	subscriptions := slices.Clone(b.subscriptions)
+	b.mu.RUnlock()
+
+	for _, event := range events {
+		for _, s := range subscriptions {
+			if len(s.types) > 0 && !slices.Contains(s.types, event.Type) {
+				continue
+			}
+			if s.queue != nil {
+				// asynchronous listeners outlive the request
+				s.enqueue(queuedEvent{ctx: context.WithoutCancel(ctx), event: event})
+			} else {
+				s.deliver(ctx, event)
+			}
+		}
+	}
+}
+
+func (s *subscription) enqueue(queued queuedEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.queue <- queued
+	}
+}
+
+// close stops accepting events and waits until the queued events have been delivered.
+func (s *subscription) close() {
+	if s.queue == nil {
+		return
+	}
+	s.mu.Lock()
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+	<-s.done
+}
+
+func (s *subscription) run() {
+	defer close(s.done)
+	for queued := range s.queue {
+		s.deliver(queued.ctx, queued.event)
+	}
+}
+
+// deliver invokes the listener and contains its panics so that a faulty listener cannot affect the SDK.
+func (s *subscription) deliver(ctx context.Context, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.ErrorContext(ctx, "Event listener panicked", "event", event.Type,
+				LogKeyFlowID, event.Transition.ProcessID, "panic", r)
+		}
+	}()
+	s.listener(ctx, event)
+}
@@ -0,0 +1,144 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DataPlaneSDK_Subscribe_Synchronous(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := newEventSDK(store)
+	var events []Event
+	dsdk.Subscribe(func(_ context.Context, event Event) {
+		events = append(events, event)
+	}, Synchronous)
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	store.EXPECT().Save(mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, dsdk.Suspend(context.Background(), "flow123", "maintenance"))
+
+	require.Len(t, events, 1)
+	assert.Equal(t, EventSuspended, events[0].Type)
+	assert.Equal(t, "flow123", events[0].Flow.ID)
+	assert.Equal(t, Suspended, events[0].Flow.State)
+	assert.Equal(t, Started, events[0].Transition.FromState)
+	assert.Equal(t, "maintenance", events[0].Transition.Reason)
+}
+
+func Test_DataPlaneSDK_Subscribe_NotPublishedOnRollback(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := newEventSDK(store)
+	dsdk.TrxContext = rollbackTrxContext{}
+	dsdk.Subscribe(func(context.Context, Event) {
+		t.Fatal("events must not be published for rolled back transactions")
+	}, Synchronous)
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	store.EXPECT().Save(mock.Anything, mock.Anything).Return(nil)
+
+	assert.Error(t, dsdk.Complete(context.Background(), "flow123"))
+}
+
+func Test_DataPlaneSDK_Subscribe_Failed(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := newEventSDK(store)
+	var types []EventType
+	dsdk.Subscribe(func(_ context.Context, event Event) {
+		types = append(types, event.Type)
+	}, Synchronous, EventFailed, EventTerminated)
+
+	store.EXPECT().FindById(mock.Anything, "failed").Return(&DataFlow{ID: "failed", State: Started}, nil)
+	store.EXPECT().FindById(mock.Anything, "terminated").Return(&DataFlow{ID: "terminated", State: Started}, nil)
+	store.EXPECT().FindById(mock.Anything, "completed").Return(&DataFlow{ID: "completed", State: Started}, nil)
+	store.EXPECT().Save(mock.Anything, mock.Anything).Return(nil)
+
+	ctx := context.Background()
+	require.NoError(t, dsdk.NotifyFailed(ctx, "failed", "disk full"))
+	require.NoError(t, dsdk.Terminate(ctx, "terminated", "cancelled"))
+	require.NoError(t, dsdk.Complete(ctx, "completed"))
+
+	assert.Equal(t, []EventType{EventFailed, EventTerminated}, types)
+}
+
+func Test_DataPlaneSDK_Subscribe_Asynchronous(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := newEventSDK(store)
+	requestCtx, cancel := context.WithCancel(context.Background())
+	var events []Event
+	var listenerCtxErr error
+	unsubscribe := dsdk.Subscribe(func(ctx context.Context, event Event) {
+		events = append(events, event)
+		listenerCtxErr = ctx.Err()
+	}, Asynchronous)
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil).Once()
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Suspended}, nil).Once()
+	store.EXPECT().Save(mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, dsdk.Suspend(requestCtx, "flow123", ""))
+	require.NoError(t, dsdk.Terminate(requestCtx, "flow123", ""))
+	cancel()
+	unsubscribe() // waits for queued events
+
+	require.Len(t, events, 2)
+	assert.Equal(t, EventSuspended, events[0].Type)
+	assert.Equal(t, EventTerminated, events[1].Type)
+	assert.NoError(t, listenerCtxErr, "asynchronous listeners must not inherit request cancellation")
+}
+
+func Test_DataPlaneSDK_Subscribe_Unsubscribe(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := newEventSDK(store)
+	calls := 0
+	unsubscribe := dsdk.Subscribe(func(context.Context, Event) { calls++ }, Synchronous)
+	unsubscribe()
+	unsubscribe()
+
+	// without subscriptions, the context is passed through unchanged
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	store.EXPECT().Save(ctx, mock.Anything).Return(nil)
+
+	require.NoError(t, dsdk.Complete(ctx, "flow123"))
+	assert.Equal(t, 0, calls)
+}
+
+func Test_DataPlaneSDK_Subscribe_ListenerPanics(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := newEventSDK(store)
+	delivered := false
+	dsdk.Subscribe(func(context.Context, Event) { panic("broken listener") }, Synchronous)
+	dsdk.Subscribe(func(context.Context, Event) { delivered = true }, Synchronous)
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	store.EXPECT().Save(mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, dsdk.Complete(context.Background(), "flow123"))
+	assert.True(t, delivered)
+}
+
+func newEventSDK(store DataplaneStore) *DataPlaneSDK {
+	return &DataPlaneSDK{
+		Store:       store,
+		TrxContext:  &mockTrxContext{},
+		onSuspend:   func(context.Context, *DataFlow) error { return nil },
+		onTerminate: func(context.Context, *DataFlow) error { return nil },
+		onComplete:  func(context.Context, *DataFlow) error { return nil },
+	}
+}
+
+// rollbackTrxContext runs the callback and then fails as if the commit had failed.
+type rollbackTrxContext struct{}
+
+func (rollbackTrxContext) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	return errors.New("commit failed")
+}
@@ -0,0 +1,155 @@
+package dsdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DataFlow_RecordsFailure(t *testing.T) {
+	flow := &DataFlow{ID: "flow123", State: Started}
+
+	require.NoError(t, flow.suspend(Failure{Code: "quota_exceeded", Message: "bucket quota exceeded", Retryable: true}))
+
+	assert.Equal(t, "bucket quota exceeded", flow.ErrorDetail)
+	assert.Equal(t, &Failure{Code: "quota_exceeded", Message: "bucket quota exceeded", Retryable: true,
+		Origin: FailureOriginLocal, Timestamp: flow.StateTimestamp}, flow.Failure)
+
+	// a duplicate transition keeps the original failure
+	require.NoError(t, flow.suspend(Failure{Code: "other"}))
+	assert.Equal(t, "quota_exceeded", flow.Failure.Code)
+
+	require.NoError(t, flow.fail(Failure{Code: "disk_full", Origin: FailureOriginCounterParty, Timestamp: 42}))
+	assert.Equal(t, Terminated, flow.State)
+	assert.Equal(t, &Failure{Code: "disk_full", Origin: FailureOriginCounterParty, Timestamp: 42}, flow.Failure)
+	assert.True(t, flow.PendingTransitions()[1].failure)
+}
+
+func Test_DataPlaneSDK_TerminateAndSuspend_RecordFailure(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	sdk := DataPlaneSDK{
+		Store:       store,
+		TrxContext:  &mockTrxContext{},
+		onSuspend:   func(context.Context, *DataFlow) error { return nil },
+		onTerminate: func(context.Context, *DataFlow) error { return nil },
+	}
+	ctx := context.Background()
+
+	store.EXPECT().FindById(ctx, "suspended").Return(&DataFlow{ID: "suspended", State: Started}, nil)
+	store.EXPECT().FindById(ctx, "terminated").Return(&DataFlow{ID: "terminated", State: Started}, nil)
+	store.EXPECT().FindById(ctx, "failed").Return(&DataFlow{ID: "failed", State: Started}, nil)
+	var saved []*DataFlow
+	store.EXPECT().Save(ctx, mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).(*DataFlow))
+	}).Return(nil)
+
+	require.NoError(t, sdk.Suspend(ctx, "suspended", "maintenance"))
+	require.NoError(t, sdk.Terminate(ctx, "terminated", "cancelled"))
+	require.NoError(t, sdk.TerminateWithFailure(ctx, "failed", Failure{Code: "disk_full", Origin: FailureOriginCounterParty}))
+
+	require.Len(t, saved, 3)
+	assert.Equal(t, &Failure{Code: FailureCodeSuspended, Message: "maintenance", Retryable: true,
+		Origin: FailureOriginControlPlane, Timestamp: saved[0].StateTimestamp}, saved[0].Failure)
+	assert.Equal(t, &Failure{Code: FailureCodeTerminated, Message: "cancelled",
+		Origin: FailureOriginControlPlane, Timestamp: saved[1].StateTimestamp}, saved[1].Failure)
+	assert.Equal(t, &Failure{Code: "disk_full", Origin: FailureOriginCounterParty, Timestamp: saved[2].StateTimestamp}, saved[2].Failure)
+}
+
+func Test_DataPlaneSDK_ResumeClearsFailure(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	var resumedFailure *Failure
+	sdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onResume: func(_ context.Context, flow *DataFlow, _ *DataPlaneSDK, _ *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			resumedFailure = flow.Failure
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+	ctx := context.Background()
+	failure := &Failure{Code: FailureCodeSuspended, Message: "maintenance", Retryable: true, Origin: FailureOriginControlPlane}
+
+	store.EXPECT().FindById(ctx, "flow123").
+		Return(&DataFlow{ID: "flow123", State: Suspended, ErrorDetail: "maintenance", Failure: failure}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.State == Started && df.Failure == nil
+	})).Return(nil)
+
+	_, err := sdk.StartById(ctx, "flow123", DataFlowStartByIdMessage{SourceDataAddress: &DataAddress{}})
+
+	require.NoError(t, err)
+	assert.Equal(t, failure, resumedFailure)
+}
+
+func Test_TransitionTo_ProcessorFailure(t *testing.T) {
+	flow := &DataFlow{ID: "flow123", State: Started}
+	require.NoError(t, transitionTo(flow, &DataFlowResponseMessage{State: Suspended, Error: "source unavailable"}))
+	assert.Equal(t, FailureCodeProcessor, flow.Failure.Code)
+	assert.Equal(t, "source unavailable", flow.Failure.Message)
+	assert.True(t, flow.Failure.Retryable)
+
+	reported := &Failure{Code: "schema_mismatch", Message: "incompatible schema"}
+	require.NoError(t, transitionTo(flow, &DataFlowResponseMessage{State: Terminated, Error: "ignored", Failure: reported}))
+	assert.Equal(t, "schema_mismatch", flow.Failure.Code)
+	assert.Equal(t, FailureOriginLocal, flow.Failure.Origin)
+	assert.Equal(t, "incompatible schema", flow.ErrorDetail)
+}
+
+func Test_DataPlaneApi_TerminateWithFailure(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	store.EXPECT().Save(mock.Anything, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.Failure != nil && df.Failure.Code == "disk_full" && df.Failure.Message == "no space left" &&
+			df.Failure.Origin == FailureOriginCounterParty && !df.Failure.Retryable
+	})).Return(nil)
+
+	body := `{"reason":"no space left","failure":{"code":"disk_full","origin":"counterparty"}}`
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dataflows/flow123/terminate", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func Test_DataPlaneApi_TerminateWithInvalidFailure(t *testing.T) {
+	handler := NewSignalingHandler(newSignalingApi(NewMockDataplaneStore(t), nil), SignalingHandlerOptions{})
+
+	body := `{"reason":"no space left","failure":{"origin":"elsewhere"}}`
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dataflows/flow123/terminate", strings.NewReader(body)))
+
+	problem := decodeProblem(t, rr, http.StatusBadRequest)
+	assert.Contains(t, problem.Violations, FieldViolation{Field: "failure.code", Rule: "required", Message: "is required"})
+	assert.Contains(t, problem.Violations, FieldViolation{Field: "failure.origin", Rule: "oneof",
+		Message: "must satisfy oneof=local counterparty controlplane"})
+}
+
+func Test_DataPlaneApi_StatusReturnsFailure(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+	failure := &Failure{Code: FailureCodeTimeout, Message: "timed out in STARTING after 3 attempts", Retryable: true,
+		Origin: FailureOriginLocal, Timestamp: 1700000000000}
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Terminated, Failure: failure}, nil)
+	store.EXPECT().FindById(mock.Anything, "flow456").Return(&DataFlow{ID: "flow456", State: Started}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response DataFlowStatusResponseMessage
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, failure, response.Failure)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow456/status", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "failure")
+}
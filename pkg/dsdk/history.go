@@ -0,0 +1,71 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"fmt"
+)
+
+type messageIDKey struct{}
+
+// ContextWithMessageID returns a copy of the context carrying the ID of the signaling message being processed. The ID is
+// recorded with the state transitions persisted in that context. The signaling API sets it for all messages carrying an
+// ID; applications calling the SDK directly can set it themselves.
+func ContextWithMessageID(ctx context.Context, messageID string) context.Context {
+	if messageID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, messageIDKey{}, messageID)
+}
+
+// MessageIDFromContext returns the ID of the signaling message being processed, if any.
+func MessageIDFromContext(ctx context.Context) (string, bool) {
+	messageID, ok := ctx.Value(messageIDKey{}).(string)
+	return messageID, ok
+}
+
+// TransitionsToPersist returns the pending transitions of the flow, completed with the message ID of the context and
+// the runtime ID of the flow. Stores call it when persisting a flow.
+func TransitionsToPersist(ctx context.Context, flow *DataFlow) []StateTransition {
+	pending := flow.PendingTransitions()
+	if len(pending) == 0 {
+		return nil
+	}
+	messageID, _ := MessageIDFromContext(ctx)
+	transitions := make([]StateTransition, len(pending))
+	for i, transition := range pending {
+		transition.ProcessID = flow.ID
+		transition.MessageID = messageID
+		transition.RuntimeID = flow.RuntimeID
+		transitions[i] = transition
+	}
+	return transitions
+}
+
+// History returns the state transitions of the flow in the order they were applied. Like Status, it reads outside a
+// transaction and takes no flow lock.
+func (dsdk *DataPlaneSDK) History(ctx context.Context, processID string) ([]StateTransition, error) {
+	transitions, err := dsdk.Store.History(ctx, processID)
+	if err != nil {
+		return nil, fmt.Errorf("reading history of data flow %s: %w", processID, err)
+	}
+	if len(transitions) > 0 {
+		return transitions, nil
+	}
+	// distinguish unknown flows from flows without recorded transitions
+	if _, err := dsdk.Store.FindById(ctx, processID); err != nil {
+		return nil, fmt.Errorf("reading history of data flow %s: %w", processID, err)
+	}
+	return transitions, nil
+}
@@ -0,0 +1,110 @@
+package dsdk
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TransitionsToPersist(t *testing.T) {
+	flow := &DataFlow{ID: "flow123", RuntimeID: "runtime1", State: Started}
+	require.NoError(t, flow.TransitionToTerminated("cancelled"))
+
+	transitions := TransitionsToPersist(ContextWithMessageID(context.Background(), "msg1"), flow)
+
+	require.Len(t, transitions, 1)
+	assert.Equal(t, StateTransition{
+		ProcessID: "flow123",
+		FromState: Started,
+		ToState:   Terminated,
+		Reason:    "cancelled",
+		Timestamp: flow.StateTimestamp,
+		MessageID: "msg1",
+		RuntimeID: "runtime1",
+	}, transitions[0])
+}
+
+func Test_DataFlowBuilder_RecordsNoTransitions(t *testing.T) {
+	callback, err := url.Parse("http://example.com/callback")
+	require.NoError(t, err)
+	flow, err := createValidBuilder(callback).State(Started).Build()
+	require.NoError(t, err)
+
+	assert.Empty(t, flow.PendingTransitions())
+}
+
+func Test_DataPlaneSDK_Start_RecordsCreation(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onStart: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+
+	ctx := context.Background()
+	message := createStartMessage()
+	var created *DataFlow
+	store.EXPECT().FindById(ctx, message.ProcessID).Return(nil, ErrNotFound)
+	store.EXPECT().Create(ctx, mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*DataFlow)
+	}).Return(nil)
+
+	_, err := dsdk.Start(ctx, message)
+
+	require.NoError(t, err)
+	transitions := created.PendingTransitions()
+	require.Len(t, transitions, 2)
+	assert.Equal(t, Uninitialized, transitions[0].FromState)
+	assert.Equal(t, Starting, transitions[0].ToState)
+	assert.Equal(t, Starting, transitions[1].FromState)
+	assert.Equal(t, Started, transitions[1].ToState)
+}
+
+func Test_DataPlaneSDK_Prepare_RecordsCreation(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onPrepare: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: Prepared}, nil
+		},
+	}
+
+	ctx := context.Background()
+	message := createPrepareMessage()
+	var created *DataFlow
+	store.EXPECT().FindById(ctx, message.ProcessID).Return(nil, ErrNotFound)
+	store.EXPECT().Create(ctx, mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*DataFlow)
+	}).Return(nil)
+
+	_, err := dsdk.Prepare(ctx, message)
+
+	require.NoError(t, err)
+	transitions := created.PendingTransitions()
+	require.Len(t, transitions, 2)
+	assert.Equal(t, Uninitialized, transitions[0].FromState)
+	assert.Equal(t, Preparing, transitions[0].ToState)
+	assert.Equal(t, Preparing, transitions[1].FromState)
+	assert.Equal(t, Prepared, transitions[1].ToState)
+}
+
+func Test_DataPlaneSDK_History_EmptyForExistingFlow(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{Store: store, TrxContext: &mockTrxContext{}}
+
+	ctx := context.Background()
+	store.EXPECT().History(ctx, "flow123").Return(nil, nil)
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{ID: "flow123"}, nil)
+
+	transitions, err := dsdk.History(ctx, "flow123")
+
+	assert.NoError(t, err)
+	assert.Empty(t, transitions)
+}
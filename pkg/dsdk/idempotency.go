@@ -0,0 +1,110 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DefaultIdempotencyRetention is the time a message response is kept for answering replays.
+const DefaultIdempotencyRetention = 24 * time.Hour
+
+// replayedResponse returns the stored response if the message has already been processed within the retention window,
+// or nil if the message is new. It must be called after the flow has been loaded so that concurrent replays of the same
+// message are serialized by the store.
+func (dsdk *DataPlaneSDK) replayedResponse(ctx context.Context, message DataFlowBaseMessage) (*DataFlowResponseMessage, error) {
+	if dsdk.IdempotencyStore == nil {
+		return nil, nil
+	}
+	record, err := dsdk.IdempotencyStore.FindByMessageId(ctx, message.MessageID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("looking up message %s: %w", message.MessageID, err)
+	}
+	if record.CreatedAt < time.Now().Add(-dsdk.replayRetention()).UnixMilli() {
+		return nil, nil // expired, the janitor has not removed it yet
+	}
+	if record.ProcessID != message.ProcessID {
+		return nil, fmt.Errorf("%w: message %s was already used for data flow %s", ErrConflict, message.MessageID, record.ProcessID)
+	}
+	response := record.Response
+	return &response, nil
+}
+
+// replayRetention returns the time a message response is answered from its idempotency record.
+func (dsdk *DataPlaneSDK) replayRetention() time.Duration {
+	if dsdk.idempotencyRetention <= 0 {
+		return DefaultIdempotencyRetention
+	}
+	return dsdk.idempotencyRetention
+}
+
+// recordResponse stores the response of a processed message in the current transaction.
+func (dsdk *DataPlaneSDK) recordResponse(ctx context.Context, message DataFlowBaseMessage, response *DataFlowResponseMessage) error {
+	if dsdk.IdempotencyStore == nil || response == nil {
+		return nil
+	}
+	err := dsdk.IdempotencyStore.Save(ctx, &IdempotencyRecord{
+		MessageID: message.MessageID,
+		ProcessID: message.ProcessID,
+		Response:  *response,
+		CreatedAt: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return fmt.Errorf("recording message %s: %w", message.MessageID, err)
+	}
+	return nil
+}
+
+// IdempotencyJanitor periodically removes idempotency records that are older than the retention window.
+type IdempotencyJanitor struct {
+	store     IdempotencyStore
+	retention time.Duration
+	logger    *slog.Logger
+}
+
+// NewIdempotencyJanitor creates a janitor for the IdempotencyStore of the SDK. Records are removed once they are older
+// than the IdempotencyRetention of the SDK, when replays are no longer answered from them.
+func NewIdempotencyJanitor(sdk *DataPlaneSDK) *IdempotencyJanitor {
+	return &IdempotencyJanitor{store: sdk.IdempotencyStore, retention: sdk.replayRetention(), logger: sdk.logger()}
+}
+
+// Purge removes all expired records and returns their number. It does nothing if the SDK has no IdempotencyStore.
+func (j *IdempotencyJanitor) Purge(ctx context.Context) (int, error) {
+	if j.store == nil {
+		return 0, nil
+	}
+	return j.store.DeleteExpired(ctx, time.Now().Add(-j.retention).UnixMilli())
+}
+
+// Run purges expired records at the given interval until the context is cancelled.
+func (j *IdempotencyJanitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := j.Purge(ctx); err != nil && ctx.Err() == nil {
+				j.logger.ErrorContext(ctx, "Error purging idempotency records", LogKeyError, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DataPlaneSDK_Start_RecordsResponse(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	idempotencyStore := NewMockIdempotencyStore(t)
+	dsdk := DataPlaneSDK{
+		Store:            store,
+		TrxContext:       &mockTrxContext{},
+		IdempotencyStore: idempotencyStore,
+		onStart: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: Started, DataplaneID: "dp1"}, nil
+		},
+	}
+	message := createStartMessage()
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "process123").Return(nil, ErrNotFound)
+	store.EXPECT().Create(ctx, mock.Anything).Return(nil)
+	idempotencyStore.EXPECT().FindByMessageId(ctx, message.MessageID).Return(nil, ErrNotFound)
+	idempotencyStore.EXPECT().Save(ctx, mock.MatchedBy(func(record *IdempotencyRecord) bool {
+		return record.MessageID == message.MessageID &&
+			record.ProcessID == "process123" &&
+			record.Response.DataplaneID == "dp1" &&
+			record.CreatedAt > 0
+	})).Return(nil)
+
+	_, err := dsdk.Start(ctx, message)
+	assert.NoError(t, err)
+}
+
+func Test_DataPlaneSDK_Start_Replay(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	idempotencyStore := NewMockIdempotencyStore(t)
+	dsdk := DataPlaneSDK{
+		Store:            store,
+		TrxContext:       &mockTrxContext{},
+		IdempotencyStore: idempotencyStore,
+		onStart: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			t.Fatal("onStart must not be invoked for a replayed message")
+			return nil, nil
+		},
+	}
+	message := createStartMessage()
+
+	ctx := context.Background()
+	// the flow has been terminated since the original message was processed
+	store.EXPECT().FindById(ctx, "process123").Return(&DataFlow{ID: "process123", State: Terminated}, nil)
+	idempotencyStore.EXPECT().FindByMessageId(ctx, message.MessageID).Return(&IdempotencyRecord{
+		MessageID: message.MessageID,
+		ProcessID: "process123",
+		Response:  DataFlowResponseMessage{State: Started, DataplaneID: "dp1"},
+		CreatedAt: time.Now().UnixMilli(),
+	}, nil)
+
+	response, err := dsdk.Start(ctx, message)
+
+	require.NoError(t, err)
+	assert.Equal(t, Started, response.State)
+	assert.Equal(t, "dp1", response.DataplaneID)
+}
+
+func Test_DataPlaneSDK_Prepare_Replay(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	idempotencyStore := NewMockIdempotencyStore(t)
+	dsdk := DataPlaneSDK{
+		Store:            store,
+		TrxContext:       &mockTrxContext{},
+		IdempotencyStore: idempotencyStore,
+		onPrepare: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			t.Fatal("onPrepare must not be invoked for a replayed message")
+			return nil, nil
+		},
+	}
+	message := createPrepareMessage()
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "process123").Return(&DataFlow{ID: "process123", State: Prepared}, nil)
+	idempotencyStore.EXPECT().FindByMessageId(ctx, message.MessageID).Return(&IdempotencyRecord{
+		MessageID: message.MessageID,
+		ProcessID: "process123",
+		Response:  DataFlowResponseMessage{State: Prepared},
+		CreatedAt: time.Now().UnixMilli(),
+	}, nil)
+
+	response, err := dsdk.Prepare(ctx, message)
+
+	require.NoError(t, err)
+	assert.Equal(t, Prepared, response.State)
+}
+
+func Test_DataPlaneSDK_Start_ReplayForDifferentProcess(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	idempotencyStore := NewMockIdempotencyStore(t)
+	dsdk := DataPlaneSDK{
+		Store:            store,
+		TrxContext:       &mockTrxContext{},
+		IdempotencyStore: idempotencyStore,
+	}
+	message := createStartMessage()
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "process123").Return(nil, ErrNotFound)
+	idempotencyStore.EXPECT().FindByMessageId(ctx, message.MessageID).Return(&IdempotencyRecord{
+		MessageID: message.MessageID,
+		ProcessID: "other",
+		CreatedAt: time.Now().UnixMilli(),
+	}, nil)
+
+	_, err := dsdk.Start(ctx, message)
+
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func Test_DataPlaneSDK_Start_ExpiredRecordIsIgnored(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	idempotencyStore := NewMockIdempotencyStore(t)
+	invoked := false
+	dsdk := DataPlaneSDK{
+		Store:                store,
+		TrxContext:           &mockTrxContext{},
+		IdempotencyStore:     idempotencyStore,
+		idempotencyRetention: time.Hour,
+		onStart: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			invoked = true
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+	message := createStartMessage()
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "process123").Return(nil, ErrNotFound)
+	store.EXPECT().Create(ctx, mock.Anything).Return(nil)
+	idempotencyStore.EXPECT().FindByMessageId(ctx, message.MessageID).Return(&IdempotencyRecord{
+		MessageID: message.MessageID,
+		ProcessID: "process123",
+		CreatedAt: time.Now().Add(-2 * time.Hour).UnixMilli(),
+	}, nil)
+	idempotencyStore.EXPECT().Save(ctx, mock.Anything).Return(nil)
+
+	_, err := dsdk.Start(ctx, message)
+
+	assert.NoError(t, err)
+	assert.True(t, invoked)
+}
+
+func Test_DataPlaneSDK_Start_RecordError(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	idempotencyStore := NewMockIdempotencyStore(t)
+	dsdk := DataPlaneSDK{
+		Store:            store,
+		TrxContext:       &mockTrxContext{},
+		IdempotencyStore: idempotencyStore,
+		onStart: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "process123").Return(nil, ErrNotFound)
+	store.EXPECT().Create(ctx, mock.Anything).Return(nil)
+	idempotencyStore.EXPECT().FindByMessageId(ctx, mock.Anything).Return(nil, ErrNotFound)
+	idempotencyStore.EXPECT().Save(ctx, mock.Anything).Return(errors.New("db down"))
+
+	_, err := dsdk.Start(ctx, createStartMessage())
+
+	assert.ErrorContains(t, err, "db down")
+}
+
+func Test_IdempotencyJanitor_Purge(t *testing.T) {
+	idempotencyStore := NewMockIdempotencyStore(t)
+	janitor := NewIdempotencyJanitor(&DataPlaneSDK{IdempotencyStore: idempotencyStore, idempotencyRetention: time.Hour})
+
+	cutoff := time.Now().Add(-time.Hour).UnixMilli()
+	idempotencyStore.EXPECT().DeleteExpired(mock.Anything, mock.MatchedBy(func(before int64) bool {
+		return before >= cutoff && before <= time.Now().Add(-time.Hour).UnixMilli()
+	})).Return(3, nil)
+
+	deleted, err := janitor.Purge(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+}
+
+func Test_IdempotencyJanitor_DefaultRetention(t *testing.T) {
+	idempotencyStore := NewMockIdempotencyStore(t)
+	sdk, err := NewDataPlaneSDKBuilder().Store(NewMockDataplaneStore(t)).TransactionContext(&mockTrxContext{}).
+		IdempotencyStore(idempotencyStore).Build()
+	require.NoError(t, err)
+
+	cutoff := time.Now().Add(-DefaultIdempotencyRetention).UnixMilli()
+	idempotencyStore.EXPECT().DeleteExpired(mock.Anything, mock.MatchedBy(func(before int64) bool {
+		return before >= cutoff && before <= time.Now().Add(-DefaultIdempotencyRetention).UnixMilli()
+	})).Return(0, nil)
+
+	_, err = NewIdempotencyJanitor(sdk).Purge(context.Background())
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,95 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedStore records a span and a latency observation for each store method. The tracer and the metrics are
+// optional.
+type instrumentedStore struct {
+	store   DataplaneStore
+	tracer  trace.Tracer
+	metrics *Metrics
+}
+
+// observe starts the span of a store method and returns the function that completes it.
+func (s *instrumentedStore) observe(ctx context.Context, method string, attributes ...attribute.KeyValue) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := startSpan(ctx, s.tracer, "DataplaneStore."+method, attributes...)
+	return ctx, func(err error) {
+		endSpan(span, err)
+		if s.metrics != nil {
+			s.metrics.observeStore(method, start, err)
+		}
+	}
+}
+
+func (s *instrumentedStore) FindById(ctx context.Context, id string) (_ *DataFlow, err error) {
+	ctx, done := s.observe(ctx, "FindById", AttributeFlowID.String(id))
+	defer func() { done(err) }()
+	return s.store.FindById(ctx, id)
+}
+
+func (s *instrumentedStore) Create(ctx context.Context, flow *DataFlow) (err error) {
+	ctx, done := s.observe(ctx, "Create", flowAttributes(flow, stateBefore(flow))...)
+	defer func() { done(err) }()
+	return s.store.Create(ctx, flow)
+}
+
+func (s *instrumentedStore) Save(ctx context.Context, flow *DataFlow) (err error) {
+	ctx, done := s.observe(ctx, "Save", flowAttributes(flow, stateBefore(flow))...)
+	defer func() { done(err) }()
+	return s.store.Save(ctx, flow)
+}
+
+func (s *instrumentedStore) Delete(ctx context.Context, id string) (err error) {
+	ctx, done := s.observe(ctx, "Delete", AttributeFlowID.String(id))
+	defer func() { done(err) }()
+	return s.store.Delete(ctx, id)
+}
+
+func (s *instrumentedStore) DeleteAll(ctx context.Context, ids []string) (_ int, err error) {
+	ctx, done := s.observe(ctx, "DeleteAll", attribute.Int("dataflow.count", len(ids)))
+	defer func() { done(err) }()
+	return s.store.DeleteAll(ctx, ids)
+}
+
+func (s *instrumentedStore) Query(ctx context.Context, query DataFlowQuery) (_ Iterator[*DataFlow], err error) {
+	ctx, done := s.observe(ctx, "Query")
+	defer func() { done(err) }()
+	return s.store.Query(ctx, query)
+}
+
+func (s *instrumentedStore) History(ctx context.Context, processID string) (_ []StateTransition, err error) {
+	ctx, done := s.observe(ctx, "History", AttributeFlowID.String(processID))
+	defer func() { done(err) }()
+	return s.store.History(ctx, processID)
+}
+
+func (s *instrumentedStore) RenewLeases(ctx context.Context, runtimeID string, expiry int64) (_ int, err error) {
+	ctx, done := s.observe(ctx, "RenewLeases")
+	defer func() { done(err) }()
+	return s.store.RenewLeases(ctx, runtimeID, expiry)
+}
+
+func (s *instrumentedStore) CountFlows(ctx context.Context) (_ []FlowCount, err error) {
+	ctx, done := s.observe(ctx, "CountFlows")
+	defer func() { done(err) }()
+	return s.store.CountFlows(ctx)
+}
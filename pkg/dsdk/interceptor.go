@@ -0,0 +1,136 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// Operation names the processor or handler being invoked.
+type Operation string
+
+const (
+	OperationPrepare   Operation = "prepare"
+	OperationStart     Operation = "start"
+	OperationResume    Operation = "resume"
+	OperationRecover   Operation = "recover"
+	OperationTerminate Operation = "terminate"
+	OperationSuspend   Operation = "suspend"
+	OperationComplete  Operation = "complete"
+)
+
+// Invocation describes a call of a processor or handler.
+type Invocation struct {
+	Operation Operation
+	Flow      *DataFlow
+	// Options are the processor options. They are nil for handlers.
+	Options *ProcessorOptions
+}
+
+// Invoker calls the next interceptor in the chain or, at its end, the processor or handler. Handlers return a nil
+// response.
+type Invoker func(ctx context.Context, invocation Invocation) (*DataFlowResponseMessage, error)
+
+// Interceptor wraps the invocation of all processors and handlers. Implementations call next to proceed and may inspect
+// or replace its result, or return without calling it to reject the invocation.
+type Interceptor func(ctx context.Context, invocation Invocation, next Invoker) (*DataFlowResponseMessage, error)
+
+// chainInterceptors returns an invoker that passes the invocation through the interceptors, the first one outermost.
+func chainInterceptors(interceptors []Interceptor, invoker Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, invocation Invocation) (*DataFlowResponseMessage, error) {
+			return interceptor(ctx, invocation, next)
+		}
+	}
+	return invoker
+}
+
+func interceptProcessor(operation Operation, processor DataFlowProcessor, interceptors []Interceptor) DataFlowProcessor {
+	if processor == nil || len(interceptors) == 0 {
+		return processor
+	}
+	return func(ctx context.Context, flow *DataFlow, sdk *DataPlaneSDK, options *ProcessorOptions) (*DataFlowResponseMessage, error) {
+		invoker := chainInterceptors(interceptors, func(ctx context.Context, invocation Invocation) (*DataFlowResponseMessage, error) {
+			return processor(ctx, invocation.Flow, sdk, invocation.Options)
+		})
+		return invoker(ctx, Invocation{Operation: operation, Flow: flow, Options: options})
+	}
+}
+
+func interceptHandler(operation Operation, handler DataFlowHandler, interceptors []Interceptor) DataFlowHandler {
+	if handler == nil || len(interceptors) == 0 {
+		return handler
+	}
+	invoker := chainInterceptors(interceptors, func(ctx context.Context, invocation Invocation) (*DataFlowResponseMessage, error) {
+		return nil, handler(ctx, invocation.Flow)
+	})
+	return func(ctx context.Context, flow *DataFlow) error {
+		_, err := invoker(ctx, Invocation{Operation: operation, Flow: flow})
+		return err
+	}
+}
+
+// RecoveryInterceptor converts panics of processors and handlers into errors wrapping ErrProcessorPanic, so that the
+// transaction is rolled back and the caller receives an error response. The stack trace is written to the logger. If
+// logger is nil, a logger writing to the handler of slog.Default() is used.
+func RecoveryInterceptor(logger *slog.Logger) Interceptor {
+	if logger == nil {
+		logger = newLogger(nil)
+	}
+	return func(ctx context.Context, invocation Invocation, next Invoker) (response *DataFlowResponseMessage, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorContext(ctx, "Panic in data flow processor", LogKeyOperation, invocation.Operation,
+					LogKeyFlowID, flowID(invocation.Flow), "panic", r, "stack", string(debug.Stack()))
+				response = nil
+				err = fmt.Errorf("%w: %s of data flow %s: %v", ErrProcessorPanic, invocation.Operation, flowID(invocation.Flow), r)
+			}
+		}()
+		return next(ctx, invocation)
+	}
+}
+
+// LoggingInterceptor writes the operation, flow, duration and outcome of each invocation to the logger. If logger is nil,
+// a logger writing to the handler of slog.Default() is used.
+func LoggingInterceptor(logger *slog.Logger) Interceptor {
+	if logger == nil {
+		logger = newLogger(nil)
+	}
+	return func(ctx context.Context, invocation Invocation, next Invoker) (*DataFlowResponseMessage, error) {
+		start := time.Now()
+		response, err := next(ctx, invocation)
+		elapsed := time.Since(start)
+		attrs := []any{LogKeyOperation, invocation.Operation, LogKeyFlowID, flowID(invocation.Flow), "duration", elapsed}
+		switch {
+		case err != nil:
+			logger.ErrorContext(ctx, "Data flow processor failed", append(attrs, LogKeyError, err)...)
+		case response != nil:
+			logger.InfoContext(ctx, "Data flow processor completed", append(attrs, "state", response.State.String())...)
+		default:
+			logger.InfoContext(ctx, "Data flow processor completed", attrs...)
+		}
+		return response, err
+	}
+}
+
+func flowID(flow *DataFlow) string {
+	if flow == nil {
+		return ""
+	}
+	return flow.ID
+}
@@ -0,0 +1,128 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Interceptor_WrapsProcessorsAndHandlers(t *testing.T) {
+	var calls []string
+	recording := func(name string) Interceptor {
+		return func(ctx context.Context, invocation Invocation, next Invoker) (*DataFlowResponseMessage, error) {
+			calls = append(calls, fmt.Sprintf("%s:%s:%s", name, invocation.Operation, invocation.Flow.ID))
+			response, err := next(ctx, invocation)
+			if response != nil {
+				calls = append(calls, fmt.Sprintf("%s:%s", name, response.State))
+			}
+			return response, err
+		}
+	}
+	var processorOptions *ProcessorOptions
+	sdk, err := NewDataPlaneSDKBuilder().
+		Store(NewMockDataplaneStore(t)).
+		TransactionContext(&mockTrxContext{}).
+		Interceptor(recording("outer"), recording("inner")).
+		OnStart(func(_ context.Context, _ *DataFlow, _ *DataPlaneSDK, options *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			processorOptions = options
+			calls = append(calls, "processor")
+			return &DataFlowResponseMessage{State: Started}, nil
+		}).
+		Build()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	flow := &DataFlow{ID: "flow123"}
+	options := &ProcessorOptions{Duplicate: true}
+	response, err := sdk.onStart(ctx, flow, sdk, options)
+
+	require.NoError(t, err)
+	assert.Equal(t, Started, response.State)
+	assert.Same(t, options, processorOptions)
+	assert.Equal(t, []string{"outer:start:flow123", "inner:start:flow123", "processor", "inner:STARTED", "outer:STARTED"}, calls)
+
+	// default handlers are wrapped as well
+	calls = nil
+	require.NoError(t, sdk.onTerminate(ctx, flow))
+	assert.Equal(t, []string{"outer:terminate:flow123", "inner:terminate:flow123"}, calls)
+}
+
+func Test_Interceptor_RejectsInvocation(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	denied := errors.New("not permitted")
+	sdk, err := NewDataPlaneSDKBuilder().
+		Store(store).
+		TransactionContext(&mockTrxContext{}).
+		Interceptor(func(ctx context.Context, invocation Invocation, next Invoker) (*DataFlowResponseMessage, error) {
+			if invocation.Operation == OperationTerminate {
+				return nil, denied
+			}
+			return next(ctx, invocation)
+		}).
+		Build()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+
+	err = sdk.Terminate(ctx, "flow123", "")
+
+	assert.ErrorIs(t, err, denied)
+	store.AssertNotCalled(t, "Save")
+}
+
+func Test_RecoveryInterceptor(t *testing.T) {
+	logger, output := newBufferLogger()
+	sdk, err := NewDataPlaneSDKBuilder().
+		Store(NewMockDataplaneStore(t)).
+		TransactionContext(&mockTrxContext{}).
+		Interceptor(RecoveryInterceptor(logger)).
+		OnPrepare(func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			panic("nil map")
+		}).
+		Build()
+	require.NoError(t, err)
+
+	response, err := sdk.onPrepare(context.Background(), &DataFlow{ID: "flow123", ParticipantID: "participant1"}, sdk, &ProcessorOptions{})
+
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, ErrProcessorPanic)
+	assert.ErrorContains(t, err, "prepare of data flow flow123: nil map")
+	records := logRecords(t, output)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Panic in data flow processor", records[0]["msg"])
+	assert.Equal(t, "nil map", records[0]["panic"])
+	assert.Equal(t, "participant1", records[0][LogKeyParticipantID])
+	assert.Contains(t, records[0]["stack"], "runtime/debug.Stack")
+}
+
+func Test_LoggingInterceptor(t *testing.T) {
+	logger, output := newBufferLogger()
+	interceptor := LoggingInterceptor(logger)
+	ctx := context.Background()
+	invocation := Invocation{Operation: OperationSuspend, Flow: &DataFlow{ID: "flow123"}}
+
+	_, _ = interceptor(ctx, invocation, func(context.Context, Invocation) (*DataFlowResponseMessage, error) {
+		return nil, nil
+	})
+	_, _ = interceptor(ctx, Invocation{Operation: OperationStart, Flow: &DataFlow{ID: "flow123"}}, func(context.Context, Invocation) (*DataFlowResponseMessage, error) {
+		return &DataFlowResponseMessage{State: Started}, nil
+	})
+	_, err := interceptor(ctx, invocation, func(context.Context, Invocation) (*DataFlowResponseMessage, error) {
+		return nil, errors.New("storage offline")
+	})
+
+	assert.EqualError(t, err, "storage offline")
+	records := logRecords(t, output)
+	require.Len(t, records, 3)
+	assert.Equal(t, "suspend", records[0][LogKeyOperation])
+	assert.Equal(t, "flow123", records[0][LogKeyFlowID])
+	assert.Contains(t, records[0], "duration")
+	assert.Equal(t, "STARTED", records[1]["state"])
+	assert.Equal(t, "ERROR", records[2]["level"])
+	assert.Equal(t, "storage offline", records[2][LogKeyError])
+}
@@ -0,0 +1,369 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// JWKS is a set of public keys used to verify JWTs. RSA, EC (P-256, P-384, P-521) and OKP (Ed25519) keys are supported.
+type JWKS struct {
+	keys []jsonWebKey
+}
+
+type jsonWebKey struct {
+	kid string
+	// alg restricts the key to one algorithm if the JWK declares it
+	alg string
+	key crypto.PublicKey
+}
+
+// jwsAlgorithms are the supported signature algorithms with their digest. EdDSA signs the input itself.
+var jwsAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+// ecdsaCurves maps the ES algorithms to the only curve they may be used with.
+var ecdsaCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKSFile reads a JSON Web Key Set from a local file.
+func LoadJWKSFile(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWKS file: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS parses a JSON Web Key Set. Keys that are not meant for signatures are skipped.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var set struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%w: invalid JWKS: %w", ErrInvalidInput, err)
+	}
+	jwks := &JWKS{}
+	for _, raw := range set.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid key %q: %w", ErrInvalidInput, raw.Kid, err)
+		}
+		if raw.Alg != "" {
+			if err := checkKeyAlgorithm(raw.Alg, key); err != nil {
+				return nil, fmt.Errorf("%w: invalid key %q: %w", ErrInvalidInput, raw.Kid, err)
+			}
+		}
+		jwks.keys = append(jwks.keys, jsonWebKey{kid: raw.Kid, alg: raw.Alg, key: key})
+	}
+	if len(jwks.keys) == 0 {
+		return nil, fmt.Errorf("%w: JWKS contains no signature keys", ErrInvalidInput)
+	}
+	return jwks, nil
+}
+
+func (k rawJWK) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// findKey returns the key for the key ID of a token. Tokens without a key ID, and tokens whose key ID is not declared
+// by the set, are only accepted if the set has a single key, so a token never selects its key by trying each of them.
+func (j *JWKS) findKey(kid string) (jsonWebKey, bool) {
+	if kid != "" {
+		for _, key := range j.keys {
+			if key.kid == kid {
+				return key, true
+			}
+		}
+	}
+	if len(j.keys) == 1 && (kid == "" || j.keys[0].kid == "") {
+		return j.keys[0], true
+	}
+	return jsonWebKey{}, false
+}
+
+// checkKeyAlgorithm verifies that the algorithm is supported and matches the type and, for ECDSA, the curve of the key.
+func checkKeyAlgorithm(alg string, key crypto.PublicKey) error {
+	if _, supported := jwsAlgorithms[alg]; !supported {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	var matches bool
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		matches = strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		matches = ecdsaCurves[alg] == k.Curve
+	case ed25519.PublicKey:
+		matches = alg == "EdDSA"
+	}
+	if !matches {
+		return fmt.Errorf("algorithm %q does not match the key", alg)
+	}
+	return nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// JWTOptions configures the claims checked by a JWTAuthenticator.
+type JWTOptions struct {
+	// Issuer is the expected "iss" claim. Empty disables the check.
+	Issuer string
+	// Audience must be contained in the "aud" claim. Empty disables the check.
+	Audience string
+	// Leeway is the tolerated clock skew when checking "exp" and "nbf".
+	Leeway time.Duration
+}
+
+// JWTAuthenticator authenticates requests carrying a signed JWT as bearer token. Tokens must have a "sub" and an "exp"
+// claim; the subject becomes the principal ID and all claims are available on the principal. The token is verified with
+// the key selected by its "kid" only, and its "alg" must be supported and match the key, see checkKeyAlgorithm.
+type JWTAuthenticator struct {
+	keys    *JWKS
+	options JWTOptions
+	now     func() time.Time
+}
+
+func NewJWTAuthenticator(keys *JWKS, options JWTOptions) *JWTAuthenticator {
+	return &JWTAuthenticator{keys: keys, options: options, now: time.Now}
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, err := bearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := a.verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	return &Principal{ID: claims["sub"].(string), Claims: claims}, nil
+}
+
+func (a *JWTAuthenticator) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token signature: %w", err)
+	}
+
+	key, found := a.keys.findKey(header.Kid)
+	if !found {
+		return nil, fmt.Errorf("no key for key ID %q", header.Kid)
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("token algorithm %q does not match key algorithm %q", header.Alg, key.alg)
+	}
+	if err := checkKeyAlgorithm(header.Alg, key.key); err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key.key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, errors.New("token signature verification failed")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]any) error {
+	now := a.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(a.options.Leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.options.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token not yet valid")
+	}
+	if subject, ok := claims["sub"].(string); !ok || subject == "" {
+		return errors.New("token has no subject")
+	}
+	if a.options.Issuer != "" && claims["iss"] != a.options.Issuer {
+		return errors.New("unexpected token issuer")
+	}
+	if a.options.Audience != "" && !containsAudience(claims["aud"], a.options.Audience) {
+		return errors.New("unexpected token audience")
+	}
+	return nil
+}
+
+func containsAudience(claim any, audience string) bool {
+	switch aud := claim.(type) {
+	case string:
+		return aud == audience
+	case []any:
+		for _, value := range aud {
+			if value == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifySignature verifies a signature with an algorithm that was checked against the key, see checkKeyAlgorithm.
+func verifySignature(alg string, key crypto.PublicKey, signed []byte, signature []byte) error {
+	if edKey, ok := key.(ed25519.PublicKey); ok {
+		if !ed25519.Verify(edKey, signed, signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+
+	hash := jwsAlgorithms[alg]
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(k, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(k, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(k, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return errors.New("invalid signature")
+}
+
+func decodeSegment(segment string, target any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
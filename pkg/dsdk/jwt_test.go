@@ -0,0 +1,221 @@
+package dsdk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_JWTAuthenticator_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	jwks := loadTestJWKS(t, map[string]any{"keys": []any{
+		map[string]any{"kty": "RSA", "kid": "rsa", "n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+		map[string]any{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encodeBigInt(ecKey.X), "y": encodeBigInt(ecKey.Y)},
+		map[string]any{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(edPublic)},
+	}})
+	authenticator := NewJWTAuthenticator(jwks, JWTOptions{Issuer: "issuer", Audience: "dataplane"})
+	claims := validClaims()
+
+	tests := map[string]string{
+		"RS256": signRS256(t, rsaKey, "rsa", claims),
+		"ES256": signES256(t, ecKey, "ec", claims),
+		"EdDSA": signEdDSA(t, edPrivate, "ed", claims),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(requestWithToken(token))
+			require.NoError(t, err)
+			assert.Equal(t, "controlplane", principal.ID)
+			assert.Equal(t, "issuer", principal.Claims["iss"])
+		})
+	}
+}
+
+func Test_JWTAuthenticator_Rejects(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks := loadTestJWKS(t, map[string]any{"keys": []any{
+		map[string]any{"kty": "RSA", "kid": "rsa", "n": encodeBigInt(key.N), "e": encodeBigInt(big.NewInt(int64(key.E)))},
+	}})
+	authenticator := NewJWTAuthenticator(jwks, JWTOptions{Issuer: "issuer", Audience: "dataplane"})
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "other"
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "other"
+	noSubject := validClaims()
+	delete(noSubject, "sub")
+	noExpiry := validClaims()
+	delete(noExpiry, "exp")
+
+	tests := map[string]string{
+		"wrong key":      signRS256(t, otherKey, "rsa", validClaims()),
+		"expired":        signRS256(t, key, "rsa", expired),
+		"wrong issuer":   signRS256(t, key, "rsa", wrongIssuer),
+		"wrong audience": signRS256(t, key, "rsa", wrongAudience),
+		"no subject":     signRS256(t, key, "rsa", noSubject),
+		"no expiry":      signRS256(t, key, "rsa", noExpiry),
+		"alg none":       encodeSegment(t, map[string]any{"alg": "none"}) + "." + encodeSegment(t, validClaims()) + ".",
+		"malformed":      "not-a-jwt",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(requestWithToken(token))
+			assert.ErrorIs(t, err, ErrUnauthorized)
+		})
+	}
+}
+
+func Test_JWTAuthenticator_RejectsKeyMismatch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaJWK := map[string]any{"kty": "RSA", "kid": "rsa", "n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E)))}
+	p384JWK := map[string]any{"kty": "EC", "kid": "ec", "crv": "P-384", "x": encodeBigInt(p384Key.X), "y": encodeBigInt(p384Key.Y)}
+	psOnlyJWK := map[string]any{"kty": "RSA", "kid": "rsa", "alg": "PS256", "n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E)))}
+
+	tests := map[string]struct {
+		keys  []any
+		token string
+	}{
+		"header alg differs from JWK alg": {[]any{psOnlyJWK}, signRS256(t, rsaKey, "rsa", validClaims())},
+		"ES256 with P-384 key":            {[]any{p384JWK}, signECDSA(t, p384Key, "ES256", crypto.SHA256, "ec", validClaims())},
+		"EdDSA with RSA key":              {[]any{rsaJWK}, signEdDSA(t, edPrivate, "rsa", validClaims())},
+		"alg not allowed":                 {[]any{rsaJWK}, signingInput(t, "HS256", "rsa", validClaims()) + ".c2lnbmF0dXJl"},
+		"no kid with several keys":        {[]any{rsaJWK, p384JWK}, signRS256(t, rsaKey, "", validClaims())},
+		"unknown kid with several keys":   {[]any{rsaJWK, p384JWK}, signRS256(t, rsaKey, "other", validClaims())},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			authenticator := NewJWTAuthenticator(loadTestJWKS(t, map[string]any{"keys": test.keys}), JWTOptions{})
+			_, err := authenticator.Authenticate(requestWithToken(test.token))
+			assert.ErrorIs(t, err, ErrUnauthorized)
+		})
+	}
+}
+
+func Test_JWTAuthenticator_SingleKeyWithoutKid(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	jwks := loadTestJWKS(t, map[string]any{"keys": []any{
+		map[string]any{"kty": "EC", "alg": "ES384", "crv": "P-384", "x": encodeBigInt(key.X), "y": encodeBigInt(key.Y)},
+	}})
+
+	_, err = NewJWTAuthenticator(jwks, JWTOptions{}).Authenticate(requestWithToken(signECDSA(t, key, "ES384", crypto.SHA384, "", validClaims())))
+
+	assert.NoError(t, err)
+}
+
+func Test_ParseJWKS_Invalid(t *testing.T) {
+	_, err := ParseJWKS([]byte(`{"keys":[]}`))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"OKP","crv":"Ed25519","alg":"ES256","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`))
+	assert.ErrorIs(t, err, ErrInvalidInput, "the JWK algorithm must match the key")
+
+	_, err = LoadJWKSFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub": "controlplane",
+		"iss": "issuer",
+		"aud": []string{"dataplane"},
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func loadTestJWKS(t *testing.T, jwks map[string]any) *JWKS {
+	t.Helper()
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	keys, err := LoadJWKSFile(path)
+	require.NoError(t, err)
+	return keys
+}
+
+func requestWithToken(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/dataflows/start", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func signingInput(t *testing.T, alg string, kid string, claims map[string]any) string {
+	return encodeSegment(t, map[string]any{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encodeSegment(t, claims)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	input := signingInput(t, "RS256", kid, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	return signECDSA(t, key, "ES256", crypto.SHA256, kid, claims)
+}
+
+// signECDSA signs with the given header algorithm and hash, which need not match the curve of the key.
+func signECDSA(t *testing.T, key *ecdsa.PrivateKey, alg string, hash crypto.Hash, kid string, claims map[string]any) string {
+	input := signingInput(t, alg, kid, claims)
+	hasher := hash.New()
+	hasher.Write([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hasher.Sum(nil))
+	require.NoError(t, err)
+	size := (key.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signEdDSA(t *testing.T, key ed25519.PrivateKey, kid string, claims map[string]any) string {
+	input := signingInput(t, "EdDSA", kid, claims)
+	return input + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(key, []byte(input)))
+}
+
+func encodeSegment(t *testing.T, value any) string {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func encodeBigInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
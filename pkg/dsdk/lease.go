@@ -0,0 +1,149 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Flow ownership in clustered deployments
+//
+// Every flow is owned by the runtime that created it (DataFlow.RuntimeID). When a lease duration is configured, the
+// owner holds a lease on its non-terminal flows (DataFlow.LeaseExpiry) and must renew it periodically, e.g. by running
+// MaintainLeases. If a runtime crashes, its leases expire and a surviving runtime takes the flows over and passes them
+// to the onRecover processor.
+//
+// A zero LeaseExpiry means the flow has no lease, e.g. because it was created while leasing was disabled. Such flows
+// are never taken over: they stay with their owner, which leases them on its next RenewLeases once leasing is enabled.
+
+// leaseExpiry returns the lease expiry for flows acquired or renewed now, or zero if leasing is disabled.
+func (dsdk *DataPlaneSDK) leaseExpiry() int64 {
+	if dsdk.leaseDuration <= 0 {
+		return 0
+	}
+	return time.Now().Add(dsdk.leaseDuration).UnixMilli()
+}
+
+// RenewLeases extends the leases of all non-terminal flows owned by this runtime and returns the number of renewed flows.
+func (dsdk *DataPlaneSDK) RenewLeases(ctx context.Context) (int, error) {
+	if dsdk.leaseDuration <= 0 {
+		return 0, nil
+	}
+	var renewed int
+	err := dsdk.execute(ctx, func(ctx context.Context) error {
+		var err error
+		renewed, err = dsdk.Store.RenewLeases(ctx, dsdk.RuntimeID, dsdk.leaseExpiry())
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("renewing leases of runtime %s: %w", dsdk.RuntimeID, err)
+	}
+	return renewed, nil
+}
+
+// TakeOverOrphans acquires non-terminal flows whose lease has expired, e.g. because their runtime crashed, and passes
+// each acquired flow to the onRecover processor. Flows acquired concurrently by another runtime are skipped.
+func (dsdk *DataPlaneSDK) TakeOverOrphans(ctx context.Context) error {
+	if dsdk.leaseDuration <= 0 {
+		return nil
+	}
+
+	var ids []string
+	err := dsdk.execute(ctx, func(ctx context.Context) error {
+		iterator, err := dsdk.Store.Query(ctx, DataFlowQuery{
+			States:             recoverableStates,
+			LeaseExpiredBefore: time.Now().UnixMilli(),
+		})
+		if err != nil {
+			return err
+		}
+		defer iterator.Close()
+		for iterator.Next() {
+			ids = append(ids, iterator.Get().ID)
+		}
+		return iterator.Error()
+	})
+	if err != nil {
+		return fmt.Errorf("finding orphaned data flows: %w", err)
+	}
+
+	var errs []error
+	for _, id := range ids {
+		acquired, err := dsdk.acquireLease(ctx, id)
+		if err != nil {
+			if !errors.Is(err, ErrConflict) {
+				dsdk.logger().ErrorContext(ctx, "Error taking over data flow", LogKeyFlowID, id, LogKeyError, err)
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if !acquired {
+			continue
+		}
+		if err := dsdk.recoverFlow(ctx, id); err != nil {
+			dsdk.logger().ErrorContext(ctx, "Error recovering data flow", LogKeyOperation, OperationRecover, LogKeyFlowID, id, LogKeyError, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// acquireLease assigns an orphaned flow to this runtime. It returns false if the lease has been renewed or taken over in
+// the meantime.
+func (dsdk *DataPlaneSDK) acquireLease(ctx context.Context, processID string) (bool, error) {
+	acquired := false
+	err := dsdk.execute(ctx, func(ctx context.Context) error {
+		flow, err := dsdk.Store.FindById(ctx, processID)
+		if err != nil {
+			return fmt.Errorf("acquiring lease of data flow %s: %w", processID, err)
+		}
+		if flow.LeaseExpiry == 0 || flow.LeaseExpiry >= time.Now().UnixMilli() || flow.State == Completed || flow.State == Terminated {
+			return nil
+		}
+		if flow.RuntimeID != dsdk.RuntimeID {
+			dsdk.logger().InfoContext(ctx, "Taking over data flow", LogKeyFlowID, flow.ID, LogKeyParticipantID, flow.ParticipantID,
+				LogKeyCounterPartyID, flow.CounterPartyID, "previous_runtime_id", flow.RuntimeID)
+		}
+		flow.RuntimeID = dsdk.RuntimeID
+		flow.LeaseExpiry = dsdk.leaseExpiry()
+		if err := dsdk.save(ctx, flow); err != nil {
+			return fmt.Errorf("acquiring lease of data flow %s: %w", processID, err)
+		}
+		acquired = true
+		return nil
+	})
+	return acquired, err
+}
+
+// MaintainLeases renews the leases of this runtime and takes over orphaned flows at the given interval until the context
+// is cancelled. The interval should be well below the lease duration.
+func (dsdk *DataPlaneSDK) MaintainLeases(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := dsdk.RenewLeases(ctx); err != nil && ctx.Err() == nil {
+				dsdk.logger().ErrorContext(ctx, "Error renewing leases", LogKeyError, err)
+			}
+			if err := dsdk.TakeOverOrphans(ctx); err != nil && ctx.Err() == nil {
+				dsdk.logger().ErrorContext(ctx, "Error taking over orphaned data flows", LogKeyError, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package dsdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DataPlaneSDK_Start_AssignsRuntimeAndLease(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:         store,
+		TrxContext:    &mockTrxContext{},
+		RuntimeID:     "runtime1",
+		leaseDuration: time.Minute,
+		onStart: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+	store.EXPECT().FindById(ctx, "process123").Return(nil, ErrNotFound)
+	store.EXPECT().Create(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.RuntimeID == "runtime1" && df.LeaseExpiry >= now+time.Minute.Milliseconds()
+	})).Return(nil)
+
+	_, err := dsdk.Start(ctx, createStartMessage())
+	assert.NoError(t, err)
+}
+
+func Test_DataPlaneSDK_RenewLeases(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:         store,
+		TrxContext:    &mockTrxContext{},
+		RuntimeID:     "runtime1",
+		leaseDuration: time.Minute,
+	}
+
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+	store.EXPECT().RenewLeases(ctx, "runtime1", mock.MatchedBy(func(expiry int64) bool {
+		return expiry >= now+time.Minute.Milliseconds()
+	})).Return(2, nil)
+
+	renewed, err := dsdk.RenewLeases(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, renewed)
+}
+
+func Test_DataPlaneSDK_RenewLeases_Disabled(t *testing.T) {
+	dsdk := DataPlaneSDK{Store: NewMockDataplaneStore(t), TrxContext: &mockTrxContext{}}
+
+	renewed, err := dsdk.RenewLeases(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, renewed)
+}
+
+func Test_DataPlaneSDK_TakeOverOrphans(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	recovered := false
+	dsdk := DataPlaneSDK{
+		Store:         store,
+		TrxContext:    &mockTrxContext{},
+		RuntimeID:     "runtime1",
+		leaseDuration: time.Minute,
+		onRecover: func(_ context.Context, flow *DataFlow, _ *DataPlaneSDK, _ *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			recovered = true
+			return &DataFlowResponseMessage{State: flow.State}, nil
+		},
+	}
+
+	ctx := context.Background()
+	expired := time.Now().Add(-time.Second).UnixMilli()
+	store.EXPECT().Query(ctx, mock.MatchedBy(func(query DataFlowQuery) bool {
+		return query.LeaseExpiredBefore > 0 && len(query.States) == len(recoverableStates)
+	})).Return(newSliceIterator(&DataFlow{ID: "flow123"}), nil)
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:          "flow123",
+		State:       Started,
+		RuntimeID:   "runtime2",
+		LeaseExpiry: expired,
+	}, nil).Once()
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.RuntimeID == "runtime1" && df.LeaseExpiry > time.Now().UnixMilli()
+	})).Return(nil).Once()
+	// recovery reloads the flow acquired by this runtime
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:          "flow123",
+		State:       Started,
+		RuntimeID:   "runtime1",
+		LeaseExpiry: time.Now().Add(time.Minute).UnixMilli(),
+	}, nil).Once()
+	store.EXPECT().Save(ctx, mock.Anything).Return(nil).Once()
+
+	err := dsdk.TakeOverOrphans(ctx)
+
+	require.NoError(t, err)
+	assert.True(t, recovered)
+}
+
+func Test_DataPlaneSDK_TakeOverOrphans_LeaseRenewedConcurrently(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:         store,
+		TrxContext:    &mockTrxContext{},
+		RuntimeID:     "runtime1",
+		leaseDuration: time.Minute,
+		onRecover: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			t.Fatal("onRecover must not be invoked for flows owned by another runtime")
+			return nil, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().Query(ctx, mock.Anything).Return(newSliceIterator(&DataFlow{ID: "flow123"}), nil)
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{
+		ID:          "flow123",
+		State:       Started,
+		RuntimeID:   "runtime2",
+		LeaseExpiry: time.Now().Add(time.Minute).UnixMilli(),
+	}, nil)
+
+	assert.NoError(t, dsdk.TakeOverOrphans(ctx))
+}
+
+func Test_DataPlaneSDK_TakeOverOrphans_FlowWithoutLease(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:         store,
+		TrxContext:    &mockTrxContext{},
+		RuntimeID:     "runtime1",
+		leaseDuration: time.Minute,
+		onRecover: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			t.Fatal("onRecover must not be invoked for flows without a lease")
+			return nil, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().Query(ctx, mock.Anything).Return(newSliceIterator(&DataFlow{ID: "flow123"}), nil)
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{ID: "flow123", State: Started, RuntimeID: "runtime2"}, nil)
+
+	assert.NoError(t, dsdk.TakeOverOrphans(ctx))
+	assert.False(t, DataFlowQuery{LeaseExpiredBefore: time.Now().UnixMilli()}.Matches(&DataFlow{State: Started}))
+}
+
+func Test_DataPlaneSDKBuilder_LeaseRequiresRuntimeID(t *testing.T) {
+	_, err := NewDataPlaneSDKBuilder().
+		Store(NewMockDataplaneStore(t)).
+		TransactionContext(&mockTrxContext{}).
+		LeaseDuration(time.Minute).
+		Build()
+
+	assert.ErrorContains(t, err, "runtime ID is required")
+}
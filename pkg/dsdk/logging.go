@@ -0,0 +1,202 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// Attribute keys of the log records written by the SDK.
+const (
+	LogKeyFlowID         = "flow_id"
+	LogKeyMessageID      = "message_id"
+	LogKeyOperation      = "operation"
+	LogKeyParticipantID  = "participant_id"
+	LogKeyCounterPartyID = "counterparty_id"
+	LogKeyError          = "error"
+)
+
+// redacted replaces secret values in log records.
+const redacted = "[REDACTED]"
+
+// secretNames are the fragments of attribute and DataAddress property names, compared case-insensitively, whose values
+// are never logged.
+var secretNames = []string{"authorization", "token", "secret", "password", "credential", "apikey", "api_key", "api-key", "private_key", "privatekey"}
+
+type logAttrsKey struct{}
+
+// ContextWithLogAttrs returns a copy of the context carrying attributes that the SDK log handler adds to every record
+// logged with the context. Attributes replace previously added attributes with the same key. The signaling API and the
+// SDK add the flow ID, operation, participant and counterparty before processors are invoked.
+func ContextWithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	existing := logAttrs(ctx)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, attr := range existing {
+		if !hasKey(attrs, attr.Key) {
+			merged = append(merged, attr)
+		}
+	}
+	return context.WithValue(ctx, logAttrsKey{}, append(merged, attrs...))
+}
+
+func logAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextWithFlow adds the log attributes of an operation on a flow to the context. Empty values are omitted.
+func contextWithFlow(ctx context.Context, operation string, flowID string, participantID string, counterPartyID string) context.Context {
+	attrs := []slog.Attr{slog.String(LogKeyOperation, operation)}
+	for _, attr := range []slog.Attr{
+		slog.String(LogKeyFlowID, flowID),
+		slog.String(LogKeyParticipantID, participantID),
+		slog.String(LogKeyCounterPartyID, counterPartyID),
+	} {
+		if attr.Value.String() != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	return ContextWithLogAttrs(ctx, attrs...)
+}
+
+// logContextInterceptor adds the log attributes of the invocation to the context passed to processors and handlers. It
+// is registered outermost by the builder.
+func logContextInterceptor(ctx context.Context, invocation Invocation, next Invoker) (*DataFlowResponseMessage, error) {
+	if flow := invocation.Flow; flow != nil {
+		ctx = contextWithFlow(ctx, string(invocation.Operation), flow.ID, flow.ParticipantID, flow.CounterPartyID)
+	}
+	return next(ctx, invocation)
+}
+
+// newLogger creates a logger that writes to the given handler, or to the handler of slog.Default() if it is nil.
+func newLogger(handler slog.Handler) *slog.Logger {
+	if handler == nil {
+		handler = slog.Default().Handler()
+	}
+	return slog.New(NewLogHandler(handler))
+}
+
+// logger returns the SDK logger or a default logger for SDKs that were not created by the builder.
+func (dsdk *DataPlaneSDK) logger() *slog.Logger {
+	if dsdk.Logger == nil {
+		return newLogger(nil)
+	}
+	return dsdk.Logger
+}
+
+// NewLogHandler wraps a handler so that records carry the attributes of the context, see ContextWithLogAttrs, and the ID
+// of the signaling message being processed. Values of attributes with secret names, such as tokens and passwords, are
+// redacted; DataAddress values redact their secret properties themselves. The builder installs it around the handler
+// passed to DataPlaneSDKBuilder.LogHandler.
+func NewLogHandler(handler slog.Handler) slog.Handler {
+	return &logHandler{handler: handler}
+}
+
+type logHandler struct {
+	handler slog.Handler
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *logHandler) Handle(ctx context.Context, record slog.Record) error {
+	own := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		own = append(own, redactAttr(attr))
+		return true
+	})
+
+	result := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	if messageID, found := MessageIDFromContext(ctx); found && !hasKey(own, LogKeyMessageID) {
+		result.AddAttrs(slog.String(LogKeyMessageID, messageID))
+	}
+	for _, attr := range logAttrs(ctx) {
+		if !hasKey(own, attr.Key) {
+			result.AddAttrs(attr)
+		}
+	}
+	result.AddAttrs(own...)
+	return h.handler.Handle(ctx, result)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redactedAttrs[i] = redactAttr(attr)
+	}
+	return &logHandler{handler: h.handler.WithAttrs(redactedAttrs)}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{handler: h.handler.WithGroup(name)}
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	return slices.ContainsFunc(attrs, func(attr slog.Attr) bool { return attr.Key == key })
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	return slices.ContainsFunc(secretNames, func(secret string) bool { return strings.Contains(name, secret) })
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	if isSecret(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		group := attr.Value.Group()
+		redactedGroup := make([]slog.Attr, len(group))
+		for i, member := range group {
+			redactedGroup[i] = redactAttr(member)
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redactedGroup...)}
+	}
+	return attr
+}
+
+// redactProperty returns a copy of a DataAddress property value without secrets. Endpoint properties are redacted by the
+// name in their key entry.
+func redactProperty(name string, value any) any {
+	if isSecret(name) {
+		return redacted
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, member := range v {
+			result[key] = redactProperty(key, member)
+		}
+		if key, ok := v["key"].(string); ok && isSecret(key) {
+			if _, found := v["value"]; found {
+				result["value"] = redacted
+			}
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, member := range v {
+			result[i] = redactProperty("", member)
+		}
+		return result
+	default:
+		return value
+	}
+}
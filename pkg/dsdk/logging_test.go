@@ -0,0 +1,159 @@
+package dsdk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LogHandler_ContextAttributes(t *testing.T) {
+	logger, output := newBufferLogger()
+	ctx := ContextWithMessageID(context.Background(), "message1")
+	ctx = contextWithFlow(ctx, string(OperationStart), "flow123", "participant1", "counterparty1")
+
+	logger.InfoContext(ctx, "started")
+	logger.InfoContext(ctx, "overridden", LogKeyOperation, "custom")
+
+	records := logRecords(t, output)
+	require.Len(t, records, 2)
+	assert.Equal(t, "message1", records[0][LogKeyMessageID])
+	assert.Equal(t, "flow123", records[0][LogKeyFlowID])
+	assert.Equal(t, "start", records[0][LogKeyOperation])
+	assert.Equal(t, "participant1", records[0][LogKeyParticipantID])
+	assert.Equal(t, "counterparty1", records[0][LogKeyCounterPartyID])
+	assert.Equal(t, "custom", records[1][LogKeyOperation])
+}
+
+func Test_ContextWithLogAttrs_ReplacesAttributes(t *testing.T) {
+	ctx := ContextWithLogAttrs(context.Background(), slog.String(LogKeyFlowID, "flow1"), slog.String("tenant", "a"))
+	ctx = ContextWithLogAttrs(ctx, slog.String(LogKeyFlowID, "flow2"))
+
+	assert.Equal(t, []slog.Attr{slog.String("tenant", "a"), slog.String(LogKeyFlowID, "flow2")}, logAttrs(ctx))
+}
+
+func Test_LogHandler_RedactsSecretAttributes(t *testing.T) {
+	logger, output := newBufferLogger()
+
+	logger.With("apiKey", "key1").Info("request", "Authorization", "Bearer token1",
+		slog.Group("client", "user", "alice", "password", "secret1"))
+
+	assert.NotContains(t, output.String(), "key1")
+	assert.NotContains(t, output.String(), "token1")
+	assert.NotContains(t, output.String(), "secret1")
+	record := logRecords(t, output)[0]
+	assert.Equal(t, redacted, record["Authorization"])
+	assert.Equal(t, "alice", record["client"].(map[string]any)["user"])
+}
+
+func Test_DataAddress_LogValue(t *testing.T) {
+	logger, output := newBufferLogger()
+	address, err := NewDataAddressBuilder().
+		Property(EndpointKey, "https://example.com/data").
+		Property("https://w3id.org/edc/v0.0.1/ns/authorization", "Bearer token1").
+		EndpointProperty("authorization", "header", "Bearer token2").
+		EndpointProperty("region", "string", "eu-central-1").
+		Build()
+	require.NoError(t, err)
+
+	logger.Info("flow", "flow", &DataFlow{ID: "flow123", SourceDataAddress: *address}, "address", address)
+
+	assert.NotContains(t, output.String(), "token1")
+	assert.NotContains(t, output.String(), "token2")
+	record := logRecords(t, output)[0]
+	logged := record["address"].(map[string]any)
+	assert.Equal(t, "https://example.com/data", logged[EndpointKey])
+	assert.Equal(t, redacted, logged["https://w3id.org/edc/v0.0.1/ns/authorization"])
+	assert.Contains(t, logged[EndpointProperties], map[string]any{"key": "region", "type": "string", "value": "eu-central-1"})
+	assert.Contains(t, logged[EndpointProperties], map[string]any{"key": "authorization", "type": "header", "value": redacted})
+	assert.Equal(t, "flow123", record["flow"].(map[string]any)["id"])
+	// the address is not modified
+	assert.Equal(t, "Bearer token1", address.Properties["https://w3id.org/edc/v0.0.1/ns/authorization"])
+}
+
+func Test_Logging_ProcessorContext(t *testing.T) {
+	handler, output := newBufferHandler()
+	var processorContext context.Context
+	sdk, err := NewDataPlaneSDKBuilder().
+		Store(NewMockDataplaneStore(t)).
+		TransactionContext(&mockTrxContext{}).
+		LogHandler(handler).
+		OnStart(func(ctx context.Context, _ *DataFlow, sdk *DataPlaneSDK, _ *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			processorContext = ctx
+			sdk.Logger.InfoContext(ctx, "starting transfer")
+			return &DataFlowResponseMessage{State: Started}, nil
+		}).
+		Build()
+	require.NoError(t, err)
+
+	flow := &DataFlow{ID: "flow123", ParticipantID: "participant1", CounterPartyID: "counterparty1"}
+	_, err = sdk.onStart(context.Background(), flow, sdk, &ProcessorOptions{})
+
+	require.NoError(t, err)
+	require.NotNil(t, processorContext)
+	record := logRecords(t, output)[0]
+	assert.Equal(t, "flow123", record[LogKeyFlowID])
+	assert.Equal(t, "start", record[LogKeyOperation])
+	assert.Equal(t, "participant1", record[LogKeyParticipantID])
+	assert.Equal(t, "counterparty1", record[LogKeyCounterPartyID])
+}
+
+func Test_Logging_SignalingError(t *testing.T) {
+	handler, output := newBufferHandler()
+	store := NewMockDataplaneStore(t)
+	sdk, err := NewDataPlaneSDKBuilder().
+		Store(store).
+		TransactionContext(&mockTrxContext{}).
+		LogHandler(handler).
+		Build()
+	require.NoError(t, err)
+	signaling := NewSignalingHandler(NewDataPlaneApi(sdk), SignalingHandlerOptions{})
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(nil, errors.New("connection reset"))
+
+	body, _ := json.Marshal(DataFlowTransitionMessage{MessageID: "message1", Reason: "cancelled"})
+	rr := httptest.NewRecorder()
+	signaling.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dataflows/flow123/terminate", bytes.NewReader(body)))
+
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	records := logRecords(t, output)
+	require.Len(t, records, 1)
+	assert.Equal(t, "ERROR", records[0]["level"])
+	assert.Equal(t, "flow123", records[0][LogKeyFlowID])
+	assert.Equal(t, "message1", records[0][LogKeyMessageID])
+	assert.Equal(t, "terminate", records[0][LogKeyOperation])
+	assert.Contains(t, records[0][LogKeyError], "connection reset")
+}
+
+func newBufferHandler() (slog.Handler, *bytes.Buffer) {
+	output := &bytes.Buffer{}
+	return slog.NewJSONHandler(output, nil), output
+}
+
+// newBufferLogger returns a logger writing JSON records to the returned buffer through the SDK log handler.
+func newBufferLogger() (*slog.Logger, *bytes.Buffer) {
+	handler, output := newBufferHandler()
+	return slog.New(NewLogHandler(handler)), output
+}
+
+func logRecords(t *testing.T, output *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	scanner := bufio.NewScanner(strings.NewReader(output.String()))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
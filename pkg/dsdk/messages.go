@@ -1,10 +1,26 @@
 package dsdk
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 )
 
-var v = validator.New()
+var v = newValidator()
+
+// newValidator creates a validator that reports fields by their JSON names.
+func newValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return validate
+}
 
 type DataFlowBaseMessage struct {
 	MessageID              string       `json:"messageID" validate:"required"`
@@ -52,6 +68,8 @@ func (d *DataFlowStartMessage) Validate() error {
 }
 
 type DataFlowStartByIdMessage struct {
+	// MessageID is optional and recorded with the resulting state transition.
+	MessageID         string       `json:"messageID,omitempty"`
 	SourceDataAddress *DataAddress `json:"sourceDataAddress,omitempty" validate:"required"`
 }
 
@@ -68,11 +86,47 @@ type DataFlowPrepareMessage struct {
 }
 
 type DataFlowTransitionMessage struct {
-	Reason string `json:"reason"`
+	// MessageID is optional and recorded with the resulting state transition.
+	MessageID string `json:"messageID,omitempty"`
+	Reason    string `json:"reason"`
+	// Failure optionally describes the error that caused the transition, e.g. a failure reported by the counterparty.
+	// Its message defaults to the reason and its origin to FailureOriginControlPlane.
+	Failure *Failure `json:"failure,omitempty"`
 }
 
 func (d *DataFlowTransitionMessage) Validate() error {
-	return nil // no special behaviour yet
+	if err := v.Struct(d); err != nil {
+		return WrapValidationError(err)
+	}
+	return nil
+}
+
+// DataFlowCompletionMessage is the optional body of a completion request.
+type DataFlowCompletionMessage struct {
+	// MessageID is optional and recorded with the resulting state transition.
+	MessageID string `json:"messageID,omitempty"`
+}
+
+func (d *DataFlowCompletionMessage) Validate() error {
+	if err := v.Struct(d); err != nil {
+		return WrapValidationError(err)
+	}
+	return nil
+}
+
+// failure returns the failure recorded for the transition.
+func (d *DataFlowTransitionMessage) failure(code string, retryable bool) Failure {
+	if d.Failure == nil {
+		return Failure{Code: code, Message: d.Reason, Retryable: retryable, Origin: FailureOriginControlPlane}
+	}
+	failure := *d.Failure
+	if failure.Message == "" {
+		failure.Message = d.Reason
+	}
+	if failure.Origin == "" {
+		failure.Origin = FailureOriginControlPlane
+	}
+	return failure
 }
 
 type DataFlowResponseMessage struct {
@@ -80,9 +134,34 @@ type DataFlowResponseMessage struct {
 	DataAddress *DataAddress  `json:"dataAddress,omitempty"`
 	State       DataFlowState `json:"state"`
 	Error       string        `json:"error"`
+	// Failure is set by processors that suspend or terminate a flow to record a structured failure. If it is not set,
+	// a failure with FailureCodeProcessor and the error is recorded.
+	Failure *Failure `json:"failure,omitempty"`
 }
 
 type DataFlowStatusResponseMessage struct {
-	State      DataFlowState `json:"state"`
-	DataFlowID string        `json:"dataFlowID"`
+	State                  DataFlowState `json:"state"`
+	DataFlowID             string        `json:"dataFlowID"`
+	SourceDataAddress      *DataAddress  `json:"sourceDataAddress,omitempty"`
+	DestinationDataAddress *DataAddress  `json:"destinationDataAddress,omitempty"`
+	// Failure describes why a suspended or terminated flow stopped.
+	Failure *Failure `json:"failure,omitempty"`
+}
+
+// DataFlowHistoryResponseMessage contains the state transitions of a data flow in the order they were applied.
+type DataFlowHistoryResponseMessage struct {
+	DataFlowID  string            `json:"dataFlowID"`
+	Transitions []StateTransition `json:"transitions"`
+}
+
+// DataFlowStateChangeMessage is sent to the callback address of a data flow to report an asynchronous state change.
+type DataFlowStateChangeMessage struct {
+	Version     string        `json:"version"`
+	MessageID   string        `json:"messageID"`
+	ProcessID   string        `json:"processID"`
+	State       DataFlowState `json:"state"`
+	DataAddress *DataAddress  `json:"dataAddress,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Failure     *Failure      `json:"failure,omitempty"`
+	Timestamp   int64         `json:"timestamp"`
 }
@@ -0,0 +1,176 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	metricsNamespace = "dsdk"
+	// flowCountTimeout bounds the store query made for each scrape.
+	flowCountTimeout = 10 * time.Second
+)
+
+// Metrics collects Prometheus metrics of a DataPlaneSDK and its signaling handler:
+//
+//	dsdk_signaling_requests_total{operation, code}              signaling requests by operation and status code
+//	dsdk_processor_duration_seconds{operation, outcome}          latency of processors and handlers
+//	dsdk_store_duration_seconds{method, outcome}                 latency of store methods
+//	dsdk_dataflow_transitions_total{event}                       committed state transitions by event type
+//	dsdk_dataflows{state, destination_type, flow_type}           flows per state and transfer type
+//
+// The flow gauges are read from the store when the metrics are scraped, so they are consistent across runtimes sharing
+// a store. Outcome is "success" or "error".
+type Metrics struct {
+	registry          *prometheus.Registry
+	requests          *prometheus.CounterVec
+	processorDuration *prometheus.HistogramVec
+	storeDuration     *prometheus.HistogramVec
+	transitions       *prometheus.CounterVec
+}
+
+// NewMetrics creates the SDK metrics in a new registry. Pass them to DataPlaneSDKBuilder.Metrics and expose them with
+// Handler.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "signaling_requests_total",
+			Help:      "Signaling requests by operation and status code.",
+		}, []string{"operation", "code"}),
+		processorDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "processor_duration_seconds",
+			Help:      "Duration of processor and handler invocations.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "outcome"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "store_duration_seconds",
+			Help:      "Duration of data flow store methods.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "outcome"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "dataflow_transitions_total",
+			Help:      "Committed data flow state transitions by event type.",
+		}, []string{"event"}),
+	}
+	m.registry.MustRegister(m.requests, m.processorDuration, m.storeDuration, m.transitions)
+	return m
+}
+
+// Registry returns the registry of the metrics, e.g. to register application metrics that are exposed by Handler.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler returns an http.Handler, usually mounted at /metrics, that serves the metrics in the Prometheus text format
+// or, if requested by the scraper, in the OpenMetrics text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// observeRequest counts a signaling request.
+func (m *Metrics) observeRequest(operation string, code int) {
+	m.requests.WithLabelValues(operation, strconv.Itoa(code)).Inc()
+}
+
+func (m *Metrics) observeStore(method string, start time.Time, err error) {
+	m.storeDuration.WithLabelValues(method, outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+// interceptor measures the duration of processor and handler invocations. It is registered by the builder.
+func (m *Metrics) interceptor() Interceptor {
+	return func(ctx context.Context, invocation Invocation, next Invoker) (*DataFlowResponseMessage, error) {
+		start := time.Now()
+		response, err := next(ctx, invocation)
+		m.processorDuration.WithLabelValues(string(invocation.Operation), outcome(err)).Observe(time.Since(start).Seconds())
+		return response, err
+	}
+}
+
+// countTransition is subscribed to the events of the SDK so that only committed transitions are counted.
+func (m *Metrics) countTransition(_ context.Context, event Event) {
+	m.transitions.WithLabelValues(string(event.Type)).Inc()
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// signalingOperations maps the routes of the signaling handler, without base path and version, to operation labels.
+var signalingOperations = map[string]string{
+	"/dataflows/prepare":        "prepare",
+	"/dataflows/start":          "start",
+	"/dataflows/{id}/start":     "start_by_id",
+	"/dataflows/{id}/suspend":   "suspend",
+	"/dataflows/{id}/terminate": "terminate",
+	"/dataflows/{id}/completed": "complete",
+	"/dataflows/{id}/status":    "status",
+	"/dataflows/{id}/history":   "history",
+}
+
+// signalingOperation returns the operation label for a matched route pattern, or "unknown" for unmatched requests.
+func signalingOperation(pattern string) string {
+	if index := strings.Index(pattern, "/dataflows/"); index >= 0 {
+		if operation, found := signalingOperations[pattern[index:]]; found {
+			return operation
+		}
+	}
+	return "unknown"
+}
+
+// flowCollector reports the number of flows per state and transfer type from the store at scrape time.
+type flowCollector struct {
+	store DataplaneStore
+	desc  *prometheus.Desc
+}
+
+func newFlowCollector(store DataplaneStore) *flowCollector {
+	return &flowCollector{
+		store: store,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "dataflows"),
+			"Data flows by state and transfer type.", []string{"state", "destination_type", "flow_type"}, nil),
+	}
+}
+
+func (c *flowCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *flowCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), flowCountTimeout)
+	defer cancel()
+	counts, err := c.store.CountFlows(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count.Count),
+			count.State.String(), count.TransferType.DestinationType, string(count.TransferType.FlowType))
+	}
+}
@@ -0,0 +1,136 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Metrics_Operation(t *testing.T) {
+	metrics := NewMetrics()
+	store := NewMockDataplaneStore(t)
+	sdk := newMeteredSDK(t, store, metrics)
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	store.EXPECT().FindById(mock.Anything, "unknown").Return(nil, ErrNotFound)
+	store.EXPECT().Save(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().CountFlows(mock.Anything).Return(nil, nil)
+
+	require.NoError(t, sdk.Terminate(context.Background(), "flow123", "cancelled"))
+	assert.Error(t, sdk.Terminate(context.Background(), "unknown", "cancelled"))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.transitions.WithLabelValues(string(EventTerminated))))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.processorDuration))
+	body := scrape(t, metrics, "")
+	assert.Contains(t, body, `dsdk_processor_duration_seconds_count{operation="terminate",outcome="success"} 1`)
+	assert.Contains(t, body, `dsdk_store_duration_seconds_count{method="FindById",outcome="success"} 1`)
+	assert.Contains(t, body, `dsdk_store_duration_seconds_count{method="FindById",outcome="error"} 1`)
+	assert.Contains(t, body, `dsdk_store_duration_seconds_count{method="Save",outcome="success"} 1`)
+}
+
+func Test_Metrics_RolledBackTransitionNotCounted(t *testing.T) {
+	metrics := NewMetrics()
+	store := NewMockDataplaneStore(t)
+	sdk := newMeteredSDK(t, store, metrics)
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	store.EXPECT().Save(mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+
+	assert.Error(t, sdk.Terminate(context.Background(), "flow123", "cancelled"))
+
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.transitions))
+}
+
+func Test_Metrics_SignalingRequests(t *testing.T) {
+	metrics := NewMetrics()
+	store := NewMockDataplaneStore(t)
+	sdk := newMeteredSDK(t, store, metrics)
+	handler := NewSignalingHandler(NewDataPlaneApi(sdk), SignalingHandlerOptions{})
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	store.EXPECT().FindById(mock.Anything, "unknown").Return(nil, ErrNotFound)
+
+	for _, path := range []string{"/dataflows/flow123/status", "/dataflows/unknown/status", "/dataflows/flow123/unsupported"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("status", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("status", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("unknown", "404")))
+}
+
+func Test_Metrics_FlowGauges(t *testing.T) {
+	metrics := NewMetrics()
+	store := NewMockDataplaneStore(t)
+	newMeteredSDK(t, store, metrics)
+
+	store.EXPECT().CountFlows(mock.Anything).Return([]FlowCount{
+		{State: Started, TransferType: TransferType{DestinationType: "HttpData", FlowType: Pull}, Count: 2},
+		{State: Suspended, TransferType: TransferType{DestinationType: "AmazonS3", FlowType: Push}, Count: 1},
+	}, nil)
+
+	body := scrape(t, metrics, "")
+	assert.Contains(t, body, `dsdk_dataflows{destination_type="HttpData",flow_type="pull",state="STARTED"} 2`)
+	assert.Contains(t, body, `dsdk_dataflows{destination_type="AmazonS3",flow_type="push",state="SUSPENDED"} 1`)
+
+	openMetrics := scrape(t, metrics, "application/openmetrics-text; version=1.0.0")
+	assert.Contains(t, openMetrics, `dsdk_dataflows{destination_type="HttpData",flow_type="pull",state="STARTED"} 2`)
+	assert.Contains(t, openMetrics, "# EOF")
+}
+
+func Test_Metrics_Disabled(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	sdk, err := NewDataPlaneSDKBuilder().Store(store).TransactionContext(&mockTrxContext{}).Build()
+	require.NoError(t, err)
+
+	assert.Same(t, store, sdk.Store)
+	assert.Nil(t, sdk.metrics)
+}
+
+func Test_Metrics_SharedBetweenSDKs(t *testing.T) {
+	metrics := NewMetrics()
+	newMeteredSDK(t, NewMockDataplaneStore(t), metrics)
+
+	_, err := NewDataPlaneSDKBuilder().
+		Store(NewMockDataplaneStore(t)).
+		TransactionContext(&mockTrxContext{}).
+		Metrics(metrics).
+		Build()
+
+	assert.ErrorContains(t, err, "registering data flow metrics")
+}
+
+func Test_SignalingOperation(t *testing.T) {
+	assert.Equal(t, "start_by_id", signalingOperation("/dataflows/{id}/start"))
+	assert.Equal(t, "prepare", signalingOperation("/api/v1/dataflows/prepare"))
+	assert.Equal(t, "unknown", signalingOperation(""))
+	assert.Equal(t, "unknown", signalingOperation("/dataflows/{id}/unsupported"))
+}
+
+func newMeteredSDK(t *testing.T, store DataplaneStore, metrics *Metrics) *DataPlaneSDK {
+	sdk, err := NewDataPlaneSDKBuilder().
+		Store(store).
+		TransactionContext(&mockTrxContext{}).
+		Metrics(metrics).
+		Build()
+	require.NoError(t, err)
+	return sdk
+}
+
+func scrape(t *testing.T, metrics *Metrics, accept string) string {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
@@ -3,7 +3,10 @@ package dsdk
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
 	"net/url"
+	"slices"
 	"time"
 )
 
@@ -23,6 +26,22 @@ type DataAddress struct {
 	Properties map[string]any `json:"properties"`
 }
 
+// IsEmpty returns true if the address is nil or has no properties.
+func (a *DataAddress) IsEmpty() bool {
+	return a == nil || len(a.Properties) == 0
+}
+
+// LogValue implements slog.LogValuer. The values of properties and endpoint properties with secret names, such as
+// authorization headers and tokens, are redacted.
+func (a DataAddress) LogValue() slog.Value {
+	keys := slices.Sorted(maps.Keys(a.Properties))
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, redactProperty(key, a.Properties[key])))
+	}
+	return slog.GroupValue(attrs...)
+}
+
 func NewDataAddressBuilder() *DataAddressBuilder {
 	return &DataAddressBuilder{
 		properties: make(map[string]any),
@@ -123,6 +142,7 @@ type DataFlow struct {
 	AgreementID            string
 	DatasetID              string
 	RuntimeID              string
+	LeaseExpiry            int64
 	UpdatedAt              int64
 	CreatedAt              int64
 	ParticipantID          string
@@ -133,9 +153,125 @@ type DataFlow struct {
 	State                  DataFlowState
 	StateCount             uint
 	StateTimestamp         int64
+	RetryCount             uint
 	SourceDataAddress      DataAddress
 	DestinationDataAddress DataAddress
-	ErrorDetail            string
+	// ErrorDetail is the reason of the last suspension or termination.
+	ErrorDetail string
+	// Failure describes why the flow was suspended or terminated. It is set by the SDK and cleared when the flow resumes.
+	Failure *Failure
+
+	// transitions are the state changes applied since the flow was built or loaded, see PendingTransitions
+	transitions []StateTransition
+}
+
+// LogValue implements slog.LogValuer. It identifies the flow and includes its data addresses without secrets.
+func (df *DataFlow) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", df.ID),
+		slog.String("state", df.State.String()),
+		slog.String("participantID", df.ParticipantID),
+		slog.String("counterPartyID", df.CounterPartyID),
+		slog.String("agreementID", df.AgreementID),
+		slog.String("destinationType", df.TransferType.DestinationType),
+		slog.String("flowType", string(df.TransferType.FlowType)),
+		slog.Any("sourceDataAddress", df.SourceDataAddress),
+		slog.Any("destinationDataAddress", df.DestinationDataAddress),
+	)
+}
+
+// FailureOrigin identifies the party that caused a failure.
+type FailureOrigin string
+
+const (
+	// FailureOriginLocal is a failure detected by this data plane, e.g. a processor error or a timeout.
+	FailureOriginLocal FailureOrigin = "local"
+	// FailureOriginCounterParty is a failure reported by the counterparty of the transfer.
+	FailureOriginCounterParty FailureOrigin = "counterparty"
+	// FailureOriginControlPlane is a suspension or termination requested by the control plane.
+	FailureOriginControlPlane FailureOrigin = "controlplane"
+)
+
+// Failure codes set by the SDK. Applications may use their own codes.
+const (
+	// FailureCodeTerminated is the code of terminations requested without a code.
+	FailureCodeTerminated = "terminated"
+	// FailureCodeSuspended is the code of suspensions requested without a code.
+	FailureCodeSuspended = "suspended"
+	// FailureCodeTimeout is the code of flows the watchdog terminated after they exceeded their state timeout.
+	FailureCodeTimeout = "timeout"
+	// FailureCodeProcessor is the code of flows a processor suspended or terminated without reporting a failure.
+	FailureCodeProcessor = "processor_error"
+	// FailureCodeTransfer is the code of transfers reported as failed with DataPlaneSDK.NotifyFailed.
+	FailureCodeTransfer = "transfer_failed"
+)
+
+// Failure is the structured record of the error that suspended or terminated a data flow. It is returned by the status
+// endpoint so that control planes can decide whether to retry a transfer.
+type Failure struct {
+	// Code is a machine-readable identifier of the error.
+	Code    string `json:"code" validate:"required"`
+	Message string `json:"message,omitempty"`
+	// Retryable indicates that the transfer may succeed if it is attempted again.
+	Retryable bool          `json:"retryable"`
+	Origin    FailureOrigin `json:"origin" validate:"omitempty,oneof=local counterparty controlplane"`
+	// Timestamp is the time the failure was recorded in epoch millis.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// StateTransition is an entry in the append-only transition history of a data flow.
+type StateTransition struct {
+	ProcessID string        `json:"processID"`
+	FromState DataFlowState `json:"fromState"`
+	ToState   DataFlowState `json:"toState"`
+	Reason    string        `json:"reason,omitempty"`
+	// Timestamp is the time of the transition in epoch millis.
+	Timestamp int64 `json:"timestamp"`
+	// MessageID is the ID of the signaling message that caused the transition, if any.
+	MessageID string `json:"messageID,omitempty"`
+	// RuntimeID is the runtime that owned the flow when the transition was persisted.
+	RuntimeID string `json:"runtimeID,omitempty"`
+
+	// failure marks terminations initiated by the data plane, see EventFailed
+	failure bool
+}
+
+// PendingTransitions returns the transitions applied since the flow was built or loaded from a store. Stores append them
+// to the transition history in the same transaction that persists the flow, then call ClearTransitions.
+func (df *DataFlow) PendingTransitions() []StateTransition {
+	return df.transitions
+}
+
+// ClearTransitions discards the pending transitions after they have been persisted.
+func (df *DataFlow) ClearTransitions() {
+	df.transitions = nil
+}
+
+// recordCreation records that a newly created flow entered its initial state. It must be called before any other
+// transition of the flow.
+func (df *DataFlow) recordCreation() {
+	df.transitions = append(df.transitions, StateTransition{
+		ProcessID: df.ID,
+		FromState: Uninitialized,
+		ToState:   df.State,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// enterState moves the flow to the given state and records the transition.
+func (df *DataFlow) enterState(state DataFlowState, reason string) {
+	now := time.Now().UnixMilli()
+	df.transitions = append(df.transitions, StateTransition{
+		ProcessID: df.ID,
+		FromState: df.State,
+		ToState:   state,
+		Reason:    reason,
+		Timestamp: now,
+	})
+	df.State = state
+	df.StateTimestamp = now
+	df.StateCount++
+	df.RetryCount = 0
 }
 
 func (df *DataFlow) TransitionToPreparing() error {
@@ -145,9 +281,7 @@ func (df *DataFlow) TransitionToPreparing() error {
 	if df.State != Uninitialized {
 		return fmt.Errorf("%w: cannot transition from %v to PREPARING", ErrInvalidTransition, df.State)
 	}
-	df.State = Preparing
-	df.StateTimestamp = time.Now().UnixMilli()
-	df.StateCount++
+	df.enterState(Preparing, "")
 	return nil
 }
 
@@ -158,9 +292,7 @@ func (df *DataFlow) TransitionToPrepared() error {
 	if df.State != Uninitialized && df.State != Preparing {
 		return fmt.Errorf("%w: cannot transition from %v to PREPARED", ErrInvalidTransition, df.State)
 	}
-	df.State = Prepared
-	df.StateTimestamp = time.Now().UnixMilli()
-	df.StateCount++
+	df.enterState(Prepared, "")
 	return nil
 }
 
@@ -171,9 +303,7 @@ func (df *DataFlow) TransitionToStarting() error {
 	if df.State != Uninitialized && df.State != Prepared {
 		return fmt.Errorf("%w: cannot transition from %v to STARTING", ErrInvalidTransition, df.State)
 	}
-	df.State = Starting
-	df.StateTimestamp = time.Now().UnixMilli()
-	df.StateCount++
+	df.enterState(Starting, "")
 	return nil
 }
 
@@ -184,9 +314,7 @@ func (df *DataFlow) TransitionToStarted() error {
 	if df.State != Uninitialized && df.State != Prepared && df.State != Starting && df.State != Suspended {
 		return fmt.Errorf("%w: cannot transition from %v to STARTED", ErrInvalidTransition, df.State)
 	}
-	df.State = Started
-	df.StateTimestamp = time.Now().UnixMilli()
-	df.StateCount++
+	df.enterState(Started, "")
 	return nil
 }
 
@@ -197,11 +325,8 @@ func (df *DataFlow) TransitionToSuspended(reason string) error {
 	if df.State != Started {
 		return fmt.Errorf("%w: cannot transition from %v to SUSPENDED", ErrInvalidTransition, df.State)
 	}
-	df.State = Suspended
-	//todo: what to do with the reason string?
 	df.ErrorDetail = reason
-	df.StateTimestamp = time.Now().UnixMilli()
-	df.StateCount++
+	df.enterState(Suspended, reason)
 	return nil
 }
 
@@ -212,9 +337,7 @@ func (df *DataFlow) TransitionToCompleted() error {
 	if df.State != Started {
 		return fmt.Errorf("%w: cannot transition from %v to COMPLETED", ErrInvalidTransition, df.State)
 	}
-	df.State = Completed
-	df.StateTimestamp = time.Now().UnixMilli()
-	df.StateCount++
+	df.enterState(Completed, "")
 	return nil
 }
 
@@ -223,13 +346,59 @@ func (df *DataFlow) TransitionToTerminated(reason string) error {
 		return nil // todo: does returning an error make sense here?
 	}
 	// Any state can transition to terminated
-	df.State = Terminated
 	df.ErrorDetail = reason
-	df.StateTimestamp = time.Now().UnixMilli()
-	df.StateCount++
+	df.enterState(Terminated, reason)
 	return nil
 }
 
+// suspend suspends the flow and records the failure that caused the suspension.
+func (df *DataFlow) suspend(failure Failure) error {
+	if df.State == Suspended {
+		return nil
+	}
+	if err := df.TransitionToSuspended(failure.Message); err != nil {
+		return err
+	}
+	df.recordFailure(failure)
+	return nil
+}
+
+// terminate terminates the flow and records the failure that caused the termination.
+func (df *DataFlow) terminate(failure Failure) error {
+	if df.State == Terminated {
+		return nil
+	}
+	if err := df.TransitionToTerminated(failure.Message); err != nil {
+		return err
+	}
+	df.recordFailure(failure)
+	return nil
+}
+
+// fail terminates the flow because it failed in the data plane rather than on request.
+func (df *DataFlow) fail(failure Failure) error {
+	if df.State == Terminated {
+		return nil
+	}
+	if err := df.terminate(failure); err != nil {
+		return err
+	}
+	df.transitions[len(df.transitions)-1].failure = true
+	return nil
+}
+
+// recordFailure sets the failure of the flow. The origin defaults to the local data plane and the timestamp to the time
+// of the last transition.
+func (df *DataFlow) recordFailure(failure Failure) {
+	if failure.Origin == "" {
+		failure.Origin = FailureOriginLocal
+	}
+	if failure.Timestamp == 0 {
+		failure.Timestamp = df.StateTimestamp
+	}
+	df.Failure = &failure
+}
+
 type DataFlowBuilder struct {
 	dataFlow DataFlow
 }
@@ -313,11 +482,21 @@ func (b *DataFlowBuilder) ErrorDetail(error string) *DataFlowBuilder {
 	return b
 }
 
+func (b *DataFlowBuilder) Failure(failure *Failure) *DataFlowBuilder {
+	b.dataFlow.Failure = failure
+	return b
+}
+
 func (b *DataFlowBuilder) RuntimeID(id string) *DataFlowBuilder {
 	b.dataFlow.RuntimeID = id
 	return b
 }
 
+func (b *DataFlowBuilder) LeaseExpiry(expiry int64) *DataFlowBuilder {
+	b.dataFlow.LeaseExpiry = expiry
+	return b
+}
+
 func (b *DataFlowBuilder) DatasetID(id string) *DataFlowBuilder {
 	b.dataFlow.DatasetID = id
 	return b
@@ -786,3 +786,34 @@ func TestDataFlow_TransitionFromZeroTimestamp(t *testing.T) {
 		t.Errorf("expected state count 1, got %d", df.StateCount)
 	}
 }
+
+// Test that transitions are recorded for the history, including reasons of intermediate states
+func TestDataFlow_RecordsPendingTransitions(t *testing.T) {
+	df := &DataFlow{ID: "flow123", State: Started}
+
+	if err := df.TransitionToSuspended("maintenance"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := df.TransitionToSuspended("maintenance"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := df.TransitionToTerminated("cancelled"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transitions := df.PendingTransitions()
+	if len(transitions) != 2 {
+		t.Fatalf("expected 2 transitions, got %d", len(transitions))
+	}
+	if transitions[0].FromState != Started || transitions[0].ToState != Suspended || transitions[0].Reason != "maintenance" {
+		t.Errorf("unexpected first transition: %+v", transitions[0])
+	}
+	if transitions[1].FromState != Suspended || transitions[1].ToState != Terminated || transitions[1].Reason != "cancelled" {
+		t.Errorf("unexpected second transition: %+v", transitions[1])
+	}
+
+	df.ClearTransitions()
+	if len(df.PendingTransitions()) != 0 {
+		t.Errorf("expected no pending transitions after clearing")
+	}
+}
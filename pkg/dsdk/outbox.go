@@ -0,0 +1,164 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultOutboxBatchSize is the number of outbox entries claimed by the relay at a time.
+	DefaultOutboxBatchSize = 100
+	// outboxClaimTimeout is the time a claimed entry is reserved for a relay. Entries that are neither delivered nor
+	// rescheduled within it, e.g. because the runtime stopped, are claimed again.
+	outboxClaimTimeout = 5 * time.Minute
+)
+
+// enqueueNotification appends a control plane notification for the flow to the outbox. It must be called in the
+// transaction that persists the state change and does nothing if no OutboxStore is configured.
+func (dsdk *DataPlaneSDK) enqueueNotification(ctx context.Context, flow *DataFlow, dataAddress *DataAddress) error {
+	if dsdk.OutboxStore == nil || dsdk.Notifier == nil {
+		return nil
+	}
+	snapshot := *flow
+	snapshot.transitions = nil
+	now := time.Now().UnixMilli()
+	err := dsdk.OutboxStore.Append(ctx, &OutboxEntry{
+		ProcessID:     flow.ID,
+		Flow:          snapshot,
+		DataAddress:   dataAddress,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return fmt.Errorf("writing notification for data flow %s to outbox: %w", flow.ID, err)
+	}
+	return nil
+}
+
+// notify sends a control plane notification directly. It must be called after the transaction has committed and does
+// nothing if an OutboxStore is configured, in which case the notification was enqueued by enqueueNotification.
+func (dsdk *DataPlaneSDK) notify(ctx context.Context, flow *DataFlow, dataAddress *DataAddress) error {
+	if dsdk.OutboxStore != nil || dsdk.Notifier == nil {
+		return nil
+	}
+	return dsdk.Notifier.Notify(ctx, flow, dataAddress)
+}
+
+// RelayOutbox delivers due outbox entries to the ControlPlaneNotifier and returns the number of delivered entries. The
+// entries of a flow are delivered in order. A failed delivery is retried after the backoff of the outbox retry policy and
+// blocks later entries of the same flow until it succeeds. An entry that still fails after MaxAttempts deliveries is
+// logged and dropped, which unblocks the later entries of its flow.
+func (dsdk *DataPlaneSDK) RelayOutbox(ctx context.Context) (_ int, err error) {
+	if dsdk.OutboxStore == nil || dsdk.Notifier == nil {
+		return 0, nil
+	}
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.RelayOutbox")
+	defer func() { endSpan(span, err) }()
+	delivered := 0
+	for {
+		now := time.Now()
+		entries, err := dsdk.OutboxStore.Claim(ctx, now.UnixMilli(), now.Add(outboxClaimTimeout).UnixMilli(), DefaultOutboxBatchSize)
+		if err != nil {
+			return delivered, fmt.Errorf("claiming outbox entries: %w", err)
+		}
+
+		batchDelivered, batchDropped := 0, 0
+		var errs []error
+		for _, entry := range entries {
+			ok, dropped, err := dsdk.relayEntry(ctx, entry)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if ok {
+				batchDelivered++
+			}
+			if dropped {
+				batchDropped++
+			}
+		}
+		delivered += batchDelivered
+		if len(errs) > 0 {
+			return delivered, errors.Join(errs...)
+		}
+		if batchDelivered == 0 && batchDropped == 0 {
+			// nothing due, or all deliveries failed and were rescheduled
+			return delivered, nil
+		}
+		// delivered and dropped entries may have unblocked later entries of the same flows
+	}
+}
+
+// relayEntry delivers a claimed entry and reports whether it was delivered or dropped after its last attempt.
+func (dsdk *DataPlaneSDK) relayEntry(ctx context.Context, entry OutboxEntry) (delivered bool, dropped bool, err error) {
+	notifyErr := dsdk.relayNotifier().Notify(ctx, &entry.Flow, entry.DataAddress)
+	if notifyErr == nil {
+		if err := dsdk.OutboxStore.Delete(ctx, entry.ID); err != nil {
+			// the entry is delivered again once its claim expires
+			return true, false, fmt.Errorf("removing delivered outbox entry %d: %w", entry.ID, err)
+		}
+		return true, false, nil
+	}
+	if ctx.Err() != nil {
+		return false, false, ctx.Err() // the claim expires and the entry is delivered later
+	}
+
+	attempt := entry.Attempts + 1
+	if attempt >= dsdk.outboxRetry.MaxAttempts {
+		dsdk.logger().ErrorContext(ctx, "Dropping undeliverable notification", LogKeyFlowID, entry.ProcessID,
+			LogKeyParticipantID, entry.Flow.ParticipantID, LogKeyCounterPartyID, entry.Flow.CounterPartyID,
+			"state", entry.Flow.State, "attempt", attempt, LogKeyError, notifyErr)
+		if err := dsdk.OutboxStore.Delete(ctx, entry.ID); err != nil {
+			return false, false, fmt.Errorf("removing undeliverable outbox entry %d: %w", entry.ID, err)
+		}
+		return false, true, nil
+	}
+	dsdk.logger().WarnContext(ctx, "Error delivering notification", LogKeyFlowID, entry.ProcessID,
+		LogKeyParticipantID, entry.Flow.ParticipantID, LogKeyCounterPartyID, entry.Flow.CounterPartyID, "attempt", attempt,
+		LogKeyError, notifyErr)
+	next := time.Now().Add(dsdk.outboxRetry.Backoff(attempt)).UnixMilli()
+	if err := dsdk.OutboxStore.Reschedule(ctx, entry.ID, next, notifyErr.Error()); err != nil {
+		return false, false, fmt.Errorf("rescheduling outbox entry %d: %w", entry.ID, err)
+	}
+	return false, false, nil
+}
+
+// relayNotifier returns the notifier for outbox deliveries. The relay retries failed entries itself, so a CallbackClient
+// makes a single attempt per delivery instead of multiplying its own retries with those of the outbox.
+func (dsdk *DataPlaneSDK) relayNotifier() ControlPlaneNotifier {
+	if client, ok := dsdk.Notifier.(*CallbackClient); ok {
+		single := *client
+		single.retry.MaxAttempts = 1
+		return &single
+	}
+	return dsdk.Notifier
+}
+
+// RunOutboxRelay calls RelayOutbox at the given interval until the context is cancelled.
+func (dsdk *DataPlaneSDK) RunOutboxRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := dsdk.RelayOutbox(ctx); err != nil && ctx.Err() == nil {
+				dsdk.logger().ErrorContext(ctx, "Error relaying outbox entries", LogKeyError, err)
+			}
+		}
+	}
+}
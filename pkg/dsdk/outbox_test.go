@@ -0,0 +1,241 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DataPlaneSDK_NotifyStarted_WritesOutbox(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	outbox := &fakeOutbox{}
+	dsdk := &DataPlaneSDK{Store: store, TrxContext: &mockTrxContext{}, Notifier: notifier, OutboxStore: outbox}
+
+	ctx := context.Background()
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{ID: "flow123", State: Starting}, nil)
+	store.EXPECT().Save(ctx, mock.Anything).Return(nil)
+	address := &DataAddress{Properties: map[string]any{"endpoint": "https://example.com"}}
+
+	require.NoError(t, dsdk.NotifyStarted(ctx, "flow123", address))
+
+	assert.Empty(t, notifier.flows, "notifications are sent by the relay")
+	require.Len(t, outbox.entries, 1)
+	assert.Equal(t, "flow123", outbox.entries[0].ProcessID)
+	assert.Equal(t, Started, outbox.entries[0].Flow.State)
+	assert.Nil(t, outbox.entries[0].Flow.PendingTransitions())
+	assert.Equal(t, address, outbox.entries[0].DataAddress)
+}
+
+func Test_DataPlaneSDK_RelayOutbox(t *testing.T) {
+	notifier := &mockNotifier{}
+	outbox := &fakeOutbox{}
+	dsdk := &DataPlaneSDK{Notifier: notifier, OutboxStore: outbox}
+
+	ctx := context.Background()
+	for _, entry := range []OutboxEntry{
+		{ProcessID: "flow1", Flow: DataFlow{ID: "flow1", State: Started}},
+		{ProcessID: "flow2", Flow: DataFlow{ID: "flow2", State: Started}},
+		{ProcessID: "flow1", Flow: DataFlow{ID: "flow1", State: Completed}},
+	} {
+		require.NoError(t, outbox.Append(ctx, &entry))
+	}
+
+	delivered, err := dsdk.RelayOutbox(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+	assert.Empty(t, outbox.entries)
+	require.Len(t, notifier.flows, 3)
+	var flow1 []DataFlowState
+	for _, flow := range notifier.flows {
+		if flow.ID == "flow1" {
+			flow1 = append(flow1, flow.State)
+		}
+	}
+	assert.Equal(t, []DataFlowState{Started, Completed}, flow1)
+}
+
+func Test_DataPlaneSDK_RelayOutbox_DeliveryFails(t *testing.T) {
+	notifier := &failingNotifier{failures: map[string]int{"flow1": 1}}
+	outbox := &fakeOutbox{}
+	dsdk := &DataPlaneSDK{
+		Notifier:    notifier,
+		OutboxStore: outbox,
+		outboxRetry: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour},
+	}
+
+	ctx := context.Background()
+	for _, processID := range []string{"flow1", "flow1", "flow2"} {
+		require.NoError(t, outbox.Append(ctx, &OutboxEntry{ProcessID: processID, Flow: DataFlow{ID: processID}}))
+	}
+
+	delivered, err := dsdk.RelayOutbox(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []string{"flow2"}, notifier.delivered)
+	require.Len(t, outbox.entries, 2, "the failed entry blocks the later entry of its flow")
+	assert.Equal(t, 1, outbox.entries[0].Attempts)
+	assert.Equal(t, "callback unavailable", outbox.entries[0].LastError)
+	assert.Greater(t, outbox.entries[0].NextAttemptAt, time.Now().Add(59*time.Minute).UnixMilli())
+
+	// retry once due
+	outbox.entries[0].NextAttemptAt = 0
+	delivered, err = dsdk.RelayOutbox(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	assert.Empty(t, outbox.entries)
+	assert.Equal(t, []string{"flow2", "flow1", "flow1"}, notifier.delivered)
+}
+
+func Test_DataPlaneSDK_RelayOutbox_DropsUndeliverableEntry(t *testing.T) {
+	notifier := &failingNotifier{failures: map[string]int{"flow1": 2}}
+	outbox := &fakeOutbox{}
+	dsdk := &DataPlaneSDK{
+		Notifier:    notifier,
+		OutboxStore: outbox,
+		Logger:      newLogger(nil),
+		outboxRetry: RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour},
+	}
+
+	ctx := context.Background()
+	for _, state := range []DataFlowState{Started, Completed} {
+		require.NoError(t, outbox.Append(ctx, &OutboxEntry{ProcessID: "flow1", Flow: DataFlow{ID: "flow1", State: state}}))
+	}
+
+	_, err := dsdk.RelayOutbox(ctx)
+	require.NoError(t, err)
+	require.Len(t, outbox.entries, 2)
+
+	outbox.entries[0].NextAttemptAt = 0
+	delivered, err := dsdk.RelayOutbox(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered, "dropping the first entry unblocks the second")
+	assert.Empty(t, outbox.entries)
+	assert.Equal(t, []string{"flow1"}, notifier.delivered)
+}
+
+func Test_DataPlaneSDK_RelayOutbox_SingleCallbackAttempt(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client, err := NewCallbackClientBuilder().RetryPolicy(fastRetryPolicy(5)).Build()
+	require.NoError(t, err)
+	outbox := &fakeOutbox{}
+	dsdk := &DataPlaneSDK{
+		Notifier:    client,
+		OutboxStore: outbox,
+		Logger:      newLogger(nil),
+		outboxRetry: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour},
+	}
+	ctx := context.Background()
+	require.NoError(t, outbox.Append(ctx, &OutboxEntry{ProcessID: "flow123", Flow: *newCallbackFlow(t, server.URL)}))
+
+	_, err = dsdk.RelayOutbox(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), requests.Load(), "the relay retries, not the callback client")
+	require.Len(t, outbox.entries, 1)
+	assert.Equal(t, 1, outbox.entries[0].Attempts)
+}
+
+func Test_DataPlaneSDK_RelayOutbox_ClaimFails(t *testing.T) {
+	dsdk := &DataPlaneSDK{Notifier: &mockNotifier{}, OutboxStore: &fakeOutbox{claimErr: errors.New("connection lost")}}
+
+	_, err := dsdk.RelayOutbox(context.Background())
+
+	assert.ErrorContains(t, err, "connection lost")
+}
+
+func Test_DataPlaneSDKBuilder_OutboxRetryPolicy(t *testing.T) {
+	builder := func() *DataPlaneSDKBuilder {
+		return NewDataPlaneSDKBuilder().Store(NewMockDataplaneStore(t)).TransactionContext(&mockTrxContext{})
+	}
+
+	sdk, err := builder().OutboxStore(&fakeOutbox{}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultOutboxRetryPolicy(), sdk.outboxRetry)
+
+	_, err = builder().OutboxRetryPolicy(RetryPolicy{MaxAttempts: 3, MaxBackoff: time.Minute}).Build()
+	assert.ErrorContains(t, err, "positive initial backoff")
+
+	_, err = builder().OutboxRetryPolicy(RetryPolicy{InitialBackoff: time.Second}).Build()
+	assert.ErrorContains(t, err, "at least one attempt")
+}
+
+// fakeOutbox keeps entries in a slice. Claims are not reserved, the relay deletes or reschedules every claimed entry.
+type fakeOutbox struct {
+	entries  []OutboxEntry
+	claimErr error
+}
+
+func (o *fakeOutbox) Append(_ context.Context, entry *OutboxEntry) error {
+	entry.ID = int64(len(o.entries) + 1)
+	if len(o.entries) > 0 {
+		entry.ID = o.entries[len(o.entries)-1].ID + 1
+	}
+	o.entries = append(o.entries, *entry)
+	return nil
+}
+
+func (o *fakeOutbox) Claim(_ context.Context, now int64, _ int64, limit int) ([]OutboxEntry, error) {
+	if o.claimErr != nil {
+		return nil, o.claimErr
+	}
+	var claimed []OutboxEntry
+	seen := make(map[string]bool)
+	for _, entry := range o.entries {
+		if !seen[entry.ProcessID] && entry.NextAttemptAt <= now && len(claimed) < limit {
+			claimed = append(claimed, entry)
+		}
+		seen[entry.ProcessID] = true
+	}
+	return claimed, nil
+}
+
+func (o *fakeOutbox) Delete(_ context.Context, id int64) error {
+	o.entries = slices.DeleteFunc(o.entries, func(entry OutboxEntry) bool { return entry.ID == id })
+	return nil
+}
+
+func (o *fakeOutbox) Reschedule(_ context.Context, id int64, nextAttemptAt int64, lastError string) error {
+	for i := range o.entries {
+		if o.entries[i].ID == id {
+			o.entries[i].Attempts++
+			o.entries[i].NextAttemptAt = nextAttemptAt
+			o.entries[i].LastError = lastError
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// failingNotifier fails the given number of deliveries per flow before succeeding.
+type failingNotifier struct {
+	failures  map[string]int
+	delivered []string
+}
+
+func (n *failingNotifier) Notify(_ context.Context, flow *DataFlow, _ *DataAddress) error {
+	if n.failures[flow.ID] > 0 {
+		n.failures[flow.ID]--
+		return errors.New("callback unavailable")
+	}
+	n.delivered = append(n.delivered, flow.ID)
+	return nil
+}
@@ -0,0 +1,190 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// CorrelationIDHeader carries the correlation ID of a signaling request. A valid ID sent by the caller is used,
+// otherwise one is generated. It is returned with every response and recorded with all log records of the request.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// LogKeyCorrelationID is the attribute key of the correlation ID in log records.
+const LogKeyCorrelationID = "correlation_id"
+
+// maxCorrelationIDLength bounds correlation IDs accepted from callers.
+const maxCorrelationIDLength = 128
+
+// Problem types of the signaling API. They are stable and can be used by clients to distinguish errors.
+const (
+	ProblemTypeValidation        = "urn:dsdk:problem:validation"
+	ProblemTypeInvalidTransition = "urn:dsdk:problem:invalid-transition"
+	ProblemTypeNotFound          = "urn:dsdk:problem:not-found"
+	ProblemTypeConflict          = "urn:dsdk:problem:conflict"
+	ProblemTypeUnauthorized      = "urn:dsdk:problem:unauthorized"
+	ProblemTypeMethodNotAllowed  = "urn:dsdk:problem:method-not-allowed"
+	ProblemTypeInternal          = "urn:dsdk:problem:internal"
+)
+
+// Problem is an RFC 7807 problem details object returned by the signaling API for failed requests.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// CorrelationID identifies the request in the log of the data plane.
+	CorrelationID string `json:"correlationId,omitempty"`
+	// Violations lists the invalid fields of a request that failed validation.
+	Violations []FieldViolation `json:"violations,omitempty"`
+}
+
+// FieldViolation describes a field of a signaling message that failed validation.
+type FieldViolation struct {
+	// Field is the JSON path of the field, e.g. "failure.code".
+	Field string `json:"field"`
+	// Rule is the validation rule that failed, e.g. "required".
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a copy of the context carrying a correlation ID. The signaling API sets it for every
+// request; the client forwards it to the data plane so that both sides log the same ID.
+func ContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	if correlationID == "" {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, correlationIDKey{}, correlationID)
+	return ContextWithLogAttrs(ctx, slog.String(LogKeyCorrelationID, correlationID))
+}
+
+// CorrelationIDFromContext returns the correlation ID of the signaling request being processed, if any.
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	correlationID, ok := ctx.Value(correlationIDKey{}).(string)
+	return correlationID, ok
+}
+
+// correlate assigns a correlation ID to each request. The ID is returned in the CorrelationIDHeader and added to the log
+// attributes of the request context.
+func correlate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		correlationID := r.Header.Get(CorrelationIDHeader)
+		if !validCorrelationID(correlationID) {
+			correlationID = uuid.NewString()
+		}
+		w.Header().Set(CorrelationIDHeader, correlationID)
+		next.ServeHTTP(w, r.WithContext(ContextWithCorrelationID(r.Context(), correlationID)))
+	})
+}
+
+func validCorrelationID(correlationID string) bool {
+	if correlationID == "" || len(correlationID) > maxCorrelationIDLength {
+		return false
+	}
+	for _, r := range correlationID {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// withCorrelationID returns the context with the correlation ID of the request, assigning one if the API is used without
+// the signaling handler.
+func withCorrelationID(ctx context.Context, w http.ResponseWriter) (context.Context, string) {
+	if correlationID, found := CorrelationIDFromContext(ctx); found {
+		return ctx, correlationID
+	}
+	correlationID := uuid.NewString()
+	w.Header().Set(CorrelationIDHeader, correlationID)
+	return ContextWithCorrelationID(ctx, correlationID), correlationID
+}
+
+// problemFor maps an error to the problem returned to the caller. The detail is fixed per problem type, since error
+// messages may contain internal information; the full error is logged with the correlation ID instead.
+func problemFor(err error) *Problem {
+	switch {
+	case errors.Is(err, ErrValidation):
+		problem := &Problem{Type: ProblemTypeValidation, Title: "Validation failed", Status: http.StatusBadRequest,
+			Detail: "The request is not valid"}
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			problem.Detail = "The request contains invalid fields"
+			problem.Violations = fieldViolations(validationErrors)
+		}
+		return problem
+	case errors.Is(err, ErrInvalidTransition):
+		return &Problem{Type: ProblemTypeInvalidTransition, Title: "Invalid state transition", Status: http.StatusBadRequest,
+			Detail: "The data flow cannot be moved to the requested state from its current state"}
+	case errors.Is(err, ErrNotFound):
+		return &Problem{Type: ProblemTypeNotFound, Title: "Not found", Status: http.StatusNotFound,
+			Detail: "The requested resource does not exist"}
+	case errors.Is(err, ErrConflict):
+		return &Problem{Type: ProblemTypeConflict, Title: "Conflict", Status: http.StatusConflict,
+			Detail: "The request conflicts with the current state of the data flow"}
+	case errors.Is(err, ErrUnauthorized):
+		return unauthorizedProblem()
+	default:
+		return internalProblem()
+	}
+}
+
+func unauthorizedProblem() *Problem {
+	return &Problem{Type: ProblemTypeUnauthorized, Title: "Unauthorized", Status: http.StatusUnauthorized,
+		Detail: "The request carries no or invalid credentials"}
+}
+
+func internalProblem() *Problem {
+	return &Problem{Type: ProblemTypeInternal, Title: "Internal error", Status: http.StatusInternalServerError,
+		Detail: "The request could not be processed. The correlation ID identifies the error in the data plane log"}
+}
+
+func fieldViolations(validationErrors validator.ValidationErrors) []FieldViolation {
+	violations := make([]FieldViolation, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		rule := fieldError.Tag()
+		message := fmt.Sprintf("must satisfy %s", rule)
+		switch {
+		case rule == "required":
+			message = "is required"
+		case fieldError.Param() != "":
+			message = fmt.Sprintf("must satisfy %s=%s", rule, fieldError.Param())
+		}
+		// the namespace starts with the name of the validated struct
+		_, field, _ := strings.Cut(fieldError.Namespace(), ".")
+		violations = append(violations, FieldViolation{Field: field, Rule: rule, Message: message})
+	}
+	return violations
+}
+
+// writeProblem writes the problem with its status code.
+func writeProblem(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set(contentType, ProblemContentType)
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,180 @@
+package dsdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Problem_ValidationViolations(t *testing.T) {
+	handler := NewSignalingHandler(newSignalingApi(NewMockDataplaneStore(t), nil), SignalingHandlerOptions{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dataflows/start", strings.NewReader(`{"processID":"flow123"}`)))
+
+	problem := decodeProblem(t, rr, http.StatusBadRequest)
+	assert.Equal(t, ProblemTypeValidation, problem.Type)
+	assert.Contains(t, problem.Violations, FieldViolation{Field: "participantID", Rule: "required", Message: "is required"})
+	assert.Contains(t, problem.Violations, FieldViolation{Field: "callbackAddress", Rule: "callback-url", Message: "must satisfy callback-url"})
+	for _, violation := range problem.Violations {
+		assert.NotEqual(t, "processID", violation.Field)
+	}
+}
+
+func Test_Problem_MalformedBody(t *testing.T) {
+	handler := NewSignalingHandler(newSignalingApi(NewMockDataplaneStore(t), nil), SignalingHandlerOptions{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dataflows/prepare", strings.NewReader(`{"processID":`)))
+
+	problem := decodeProblem(t, rr, http.StatusBadRequest)
+	assert.Equal(t, ProblemTypeValidation, problem.Type)
+	assert.Empty(t, problem.Violations)
+}
+
+func Test_Problem_InvalidCompletionBody(t *testing.T) {
+	handler := NewSignalingHandler(newSignalingApi(NewMockDataplaneStore(t), nil), SignalingHandlerOptions{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dataflows/flow123/completed", strings.NewReader(`{"messageID":5}`)))
+
+	problem := decodeProblem(t, rr, http.StatusBadRequest)
+	assert.Equal(t, ProblemTypeValidation, problem.Type)
+}
+
+func Test_Problem_Types(t *testing.T) {
+	tests := map[string]struct {
+		err         error
+		status      int
+		problemType string
+	}{
+		"not found":          {ErrNotFound, http.StatusNotFound, ProblemTypeNotFound},
+		"conflict":           {ErrConflict, http.StatusConflict, ProblemTypeConflict},
+		"invalid transition": {ErrInvalidTransition, http.StatusBadRequest, ProblemTypeInvalidTransition},
+		"validation":         {NewValidationError("invalid id"), http.StatusBadRequest, ProblemTypeValidation},
+		"internal":           {errors.New("connection reset"), http.StatusInternalServerError, ProblemTypeInternal},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := NewMockDataplaneStore(t)
+			store.EXPECT().FindById(mock.Anything, "flow123").Return(nil, test.err)
+			handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil))
+
+			problem := decodeProblem(t, rr, test.status)
+			assert.Equal(t, test.problemType, problem.Type)
+			assert.Equal(t, test.status, problem.Status)
+			assert.NotEmpty(t, problem.Title)
+		})
+	}
+}
+
+func Test_Problem_InternalErrorNotDisclosed(t *testing.T) {
+	logHandler, output := newBufferHandler()
+	store := NewMockDataplaneStore(t)
+	sdk, err := NewDataPlaneSDKBuilder().Store(store).TransactionContext(&mockTrxContext{}).LogHandler(logHandler).Build()
+	require.NoError(t, err)
+	handler := NewSignalingHandler(NewDataPlaneApi(sdk), SignalingHandlerOptions{})
+
+	store.EXPECT().FindById(mock.Anything, "flow123").
+		Return(nil, errors.New(`pq: relation "data_flows" does not exist`))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil))
+
+	problem := decodeProblem(t, rr, http.StatusInternalServerError)
+	assert.Equal(t, ProblemTypeInternal, problem.Type)
+	assert.NotContains(t, rr.Body.String(), "data_flows")
+	records := logRecords(t, output)
+	require.Len(t, records, 1)
+	assert.Equal(t, problem.CorrelationID, records[0][LogKeyCorrelationID])
+	assert.Contains(t, records[0][LogKeyError], "data_flows")
+}
+
+func Test_Problem_ClientErrorDetailNotDisclosed(t *testing.T) {
+	logHandler, output := newBufferHandler()
+	store := NewMockDataplaneStore(t)
+	sdk, err := NewDataPlaneSDKBuilder().Store(store).TransactionContext(&mockTrxContext{}).LogHandler(logHandler).Build()
+	require.NoError(t, err)
+	handler := NewSignalingHandler(NewDataPlaneApi(sdk), SignalingHandlerOptions{})
+
+	store.EXPECT().FindById(mock.Anything, "flow123").
+		Return(nil, fmt.Errorf("%w: lock held by runtime-7 on db-replica-2", ErrConflict))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil))
+
+	problem := decodeProblem(t, rr, http.StatusConflict)
+	assert.Equal(t, "The request conflicts with the current state of the data flow", problem.Detail)
+	assert.NotContains(t, rr.Body.String(), "db-replica-2")
+	records := logRecords(t, output)
+	require.Len(t, records, 1)
+	assert.Equal(t, problem.CorrelationID, records[0][LogKeyCorrelationID])
+	assert.Contains(t, records[0][LogKeyError], "db-replica-2")
+}
+
+func Test_Problem_CorrelationID(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+
+	req := httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil)
+	req.Header.Set(CorrelationIDHeader, "request-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "request-123", rr.Header().Get(CorrelationIDHeader))
+
+	req = httptest.NewRequest(http.MethodGet, "/dataflows/flow123/unsupported", nil)
+	req.Header.Set(CorrelationIDHeader, "not valid")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	problem := decodeProblem(t, rr, http.StatusNotFound)
+	assert.NotEqual(t, "not valid", problem.CorrelationID)
+}
+
+func Test_Problem_Unauthorized(t *testing.T) {
+	handler := NewSignalingHandler(newSignalingApi(NewMockDataplaneStore(t), nil), SignalingHandlerOptions{
+		Authenticator: NewBearerTokenAuthenticator(map[string]string{"token": "control-plane"}),
+	})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil))
+
+	problem := decodeProblem(t, rr, http.StatusUnauthorized)
+	assert.Equal(t, ProblemTypeUnauthorized, problem.Type)
+	assert.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+}
+
+func Test_Problem_ApiWithoutSignalingHandler(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(nil, ErrNotFound)
+	api := NewDataPlaneApi(&DataPlaneSDK{Store: store, TrxContext: &mockTrxContext{}})
+
+	rr := httptest.NewRecorder()
+	api.Status(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil).WithContext(context.Background()), "flow123")
+
+	decodeProblem(t, rr, http.StatusNotFound)
+}
+
+// decodeProblem asserts a problem response with the given status and a correlation ID matching the response header.
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder, status int) Problem {
+	require.Equal(t, status, rr.Code)
+	assert.Equal(t, ProblemContentType, rr.Header().Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+	assert.NotEmpty(t, problem.CorrelationID)
+	assert.Equal(t, rr.Header().Get(CorrelationIDHeader), problem.CorrelationID)
+	return problem
+}
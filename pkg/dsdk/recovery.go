@@ -0,0 +1,140 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// recoverableStates are the non-terminal states of flows that may need to be resumed after a restart.
+var recoverableStates = []DataFlowState{Preparing, Prepared, Starting, Started, Suspended}
+
+// Recover is called on startup and passes every non-terminal flow owned by this runtime (or every non-terminal flow if
+// no runtime ID is configured) to the onRecover processor, which can restart transfers, re-issue credentials or
+// terminate the flow. The state returned by the processor is
+// persisted and, if it differs from the stored state, reported to the control plane. Each flow is recovered in its own
+// transaction; failures are collected and do not stop the recovery of the remaining flows.
+func (dsdk *DataPlaneSDK) Recover(ctx context.Context) (err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.Recover")
+	defer func() { endSpan(span, err) }()
+	ids, err := dsdk.recoverableFlows(ctx)
+	if err != nil {
+		return fmt.Errorf("recovering data flows: %w", err)
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := dsdk.recoverFlow(ctx, id); err != nil {
+			dsdk.logger().ErrorContext(ctx, "Error recovering data flow", LogKeyOperation, OperationRecover, LogKeyFlowID, id, LogKeyError, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recoverableFlows collects the IDs first so that no cursor is held open while flows are processed.
+func (dsdk *DataPlaneSDK) recoverableFlows(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := dsdk.execute(ctx, func(ctx context.Context) error {
+		iterator, err := dsdk.Store.Query(ctx, DataFlowQuery{States: recoverableStates, RuntimeID: dsdk.RuntimeID})
+		if err != nil {
+			return err
+		}
+		defer iterator.Close()
+		for iterator.Next() {
+			ids = append(ids, iterator.Get().ID)
+		}
+		return iterator.Error()
+	})
+	return ids, err
+}
+
+func (dsdk *DataPlaneSDK) recoverFlow(ctx context.Context, processID string) (err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.RecoverFlow", AttributeFlowID.String(processID))
+	defer func() { endSpan(span, err) }()
+	var flow *DataFlow
+	var response *DataFlowResponseMessage
+	changed := false
+	err = dsdk.execute(ctx, func(ctx context.Context) error {
+		found, err := dsdk.Store.FindById(ctx, processID)
+		if err != nil {
+			return fmt.Errorf("recovering data flow %s: %w", processID, err)
+		}
+		if found.State == Completed || found.State == Terminated {
+			return nil // finished since the flows were collected
+		}
+
+		previous := found.State
+		response, err = dsdk.onRecover(ctx, found, dsdk, &ProcessorOptions{SourceDataAddress: &found.SourceDataAddress})
+		if err != nil {
+			return fmt.Errorf("recovering data flow %s: %w", processID, err)
+		}
+		if err := transitionTo(found, response); err != nil {
+			return fmt.Errorf("onRecover returned an invalid state: %w", err)
+		}
+		if dsdk.leaseDuration > 0 {
+			found.LeaseExpiry = dsdk.leaseExpiry()
+		}
+
+		if err := dsdk.save(ctx, found); err != nil {
+			return fmt.Errorf("recovering data flow %s: %w", processID, err)
+		}
+		flow = found
+		changed = found.State != previous
+		if !changed {
+			return nil
+		}
+		return dsdk.enqueueNotification(ctx, found, response.DataAddress)
+	})
+	if err != nil || !changed {
+		return err
+	}
+	return dsdk.notify(ctx, flow, response.DataAddress)
+}
+
+// transitionTo moves the flow to the state of the processor response. The failure of the response, or its error, is
+// recorded for suspension and termination; a processor terminating a flow is treated as a failure.
+func transitionTo(flow *DataFlow, response *DataFlowResponseMessage) error {
+	if response.State == flow.State {
+		return nil
+	}
+	switch response.State {
+	case Preparing:
+		return flow.TransitionToPreparing()
+	case Prepared:
+		return flow.TransitionToPrepared()
+	case Starting:
+		return flow.TransitionToStarting()
+	case Started:
+		return flow.TransitionToStarted()
+	case Suspended:
+		return flow.suspend(processorFailure(response, true))
+	case Completed:
+		return flow.TransitionToCompleted()
+	case Terminated:
+		return flow.fail(processorFailure(response, false))
+	default:
+		return fmt.Errorf("%w: unsupported state %s", ErrInvalidTransition, response.State)
+	}
+}
+
+// processorFailure returns the failure reported by a processor. If it reported none, a failure with
+// FailureCodeProcessor and the response error is returned.
+func processorFailure(response *DataFlowResponseMessage, retryable bool) Failure {
+	if response.Failure != nil {
+		return *response.Failure
+	}
+	return Failure{Code: FailureCodeProcessor, Message: response.Error, Retryable: retryable}
+}
@@ -0,0 +1,141 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DataPlaneSDK_Recover(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	var recovered []string
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		Notifier:   notifier,
+		onRecover: func(_ context.Context, flow *DataFlow, _ *DataPlaneSDK, _ *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			recovered = append(recovered, flow.ID)
+			if flow.ID == "starting" {
+				return &DataFlowResponseMessage{State: Terminated, Error: "transfer lost on restart"}, nil
+			}
+			return &DataFlowResponseMessage{State: flow.State}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().Query(ctx, DataFlowQuery{States: recoverableStates}).
+		Return(newSliceIterator(&DataFlow{ID: "started"}, &DataFlow{ID: "starting"}), nil)
+	store.EXPECT().FindById(ctx, "started").Return(&DataFlow{ID: "started", State: Started}, nil)
+	store.EXPECT().FindById(ctx, "starting").Return(&DataFlow{ID: "starting", State: Starting}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.ID == "started" && df.State == Started
+	})).Return(nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.ID == "starting" && df.State == Terminated && df.ErrorDetail == "transfer lost on restart"
+	})).Return(nil)
+
+	err := dsdk.Recover(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"started", "starting"}, recovered)
+	// only the changed flow is reported
+	require.Len(t, notifier.flows, 1)
+	assert.Equal(t, "starting", notifier.flows[0].ID)
+	assert.Equal(t, Terminated, notifier.flows[0].State)
+}
+
+func Test_DataPlaneSDK_Recover_SkipsFinishedFlows(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onRecover: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			t.Fatal("onRecover must not be invoked for finished flows")
+			return nil, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().Query(ctx, mock.Anything).Return(newSliceIterator(&DataFlow{ID: "flow123"}), nil)
+	// terminated after the flows were collected
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{ID: "flow123", State: Terminated}, nil)
+
+	assert.NoError(t, dsdk.Recover(ctx))
+}
+
+func Test_DataPlaneSDK_Recover_ContinuesAfterError(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onRecover: func(_ context.Context, flow *DataFlow, _ *DataPlaneSDK, _ *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			if flow.ID == "flow1" {
+				return nil, errors.New("credentials unavailable")
+			}
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().Query(ctx, mock.Anything).Return(newSliceIterator(&DataFlow{ID: "flow1"}, &DataFlow{ID: "flow2"}), nil)
+	store.EXPECT().FindById(ctx, "flow1").Return(&DataFlow{ID: "flow1", State: Started}, nil)
+	store.EXPECT().FindById(ctx, "flow2").Return(&DataFlow{ID: "flow2", State: Starting}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.ID == "flow2" && df.State == Started
+	})).Return(nil)
+
+	err := dsdk.Recover(ctx)
+
+	assert.ErrorContains(t, err, "credentials unavailable")
+}
+
+func Test_DataPlaneSDK_Recover_InvalidState(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		onRecover: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return &DataFlowResponseMessage{State: Preparing}, nil
+		},
+	}
+
+	ctx := context.Background()
+	store.EXPECT().Query(ctx, mock.Anything).Return(newSliceIterator(&DataFlow{ID: "flow123"}), nil)
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+
+	err := dsdk.Recover(ctx)
+
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+// sliceIterator is an Iterator over a fixed set of flows.
+type sliceIterator struct {
+	flows []*DataFlow
+	index int
+}
+
+func newSliceIterator(flows ...*DataFlow) *sliceIterator {
+	return &sliceIterator{flows: flows, index: -1}
+}
+
+func (it *sliceIterator) Next() bool {
+	it.index++
+	return it.index < len(it.flows)
+}
+
+func (it *sliceIterator) Get() *DataFlow {
+	return it.flows[it.index]
+}
+
+func (it *sliceIterator) Error() error {
+	return nil
+}
+
+func (it *sliceIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,223 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultPurgeBatchSize is the number of flows removed per transaction if the retention policy does not set a batch size.
+const DefaultPurgeBatchSize = 100
+
+// RetentionPolicy defines which finished flows are removed by PurgeFlows. A flow is removed if it exceeds any of the
+// configured limits; zero values disable a limit.
+type RetentionPolicy struct {
+	// States are the states of flows that may be removed. Defaults to COMPLETED and TERMINATED. Only these states are
+	// allowed, so active flows are never removed.
+	States []DataFlowState
+	// MaxAge removes flows that have not been updated for the given duration.
+	MaxAge time.Duration
+	// KeepPerAgreement keeps the given number of most recently created flows per agreement and removes older ones. Flows
+	// without an agreement are not subject to this limit.
+	KeepPerAgreement int
+	// BatchSize is the number of flows removed per transaction and read per store query. Defaults to
+	// DefaultPurgeBatchSize.
+	BatchSize int
+}
+
+// FlowArchiver receives each flow before it is removed by PurgeFlows, e.g. to copy it to cold storage. The history of
+// the flow is removed with it; the archiver can read it with DataplaneStore.History in the purge transaction. If it
+// returns an error, the flows of the current batch are kept.
+type FlowArchiver func(ctx context.Context, flow *DataFlow) error
+
+// PurgeFlows removes the finished flows selected by the retention policy and returns the number of removed flows.
+// Flows are read and removed in batches, each in its own transaction, so the expired flows are never held in memory at
+// once. A batch that fails is kept and skipped, and the remaining batches are still purged.
+func (dsdk *DataPlaneSDK) PurgeFlows(ctx context.Context) (_ int, err error) {
+	if dsdk.retention == nil {
+		return 0, nil
+	}
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.PurgeFlows")
+	defer func() { endSpan(span, err) }()
+	policy := dsdk.retention
+	states := dsdk.retentionStates()
+
+	purged := 0
+	var errs []error
+	if policy.MaxAge > 0 {
+		query := DataFlowQuery{States: states, UpdatedBefore: time.Now().Add(-policy.MaxAge).UnixMilli()}
+		deleted, err := dsdk.purgeMatching(ctx, query, 0)
+		purged += deleted
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if policy.KeepPerAgreement > 0 {
+		counts, err := dsdk.countPerAgreement(ctx, states)
+		if err != nil {
+			return purged, errors.Join(append(errs, fmt.Errorf("counting data flows per agreement: %w", err))...)
+		}
+		for agreementID, count := range counts {
+			if excess := count - policy.KeepPerAgreement; excess > 0 {
+				// flows are returned oldest first, so the oldest flows exceeding the limit are removed
+				deleted, err := dsdk.purgeMatching(ctx, DataFlowQuery{States: states, AgreementID: agreementID}, excess)
+				purged += deleted
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return purged, errors.Join(errs...)
+}
+
+// RunPurger calls PurgeFlows at the given interval until the context is cancelled.
+func (dsdk *DataPlaneSDK) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := dsdk.PurgeFlows(ctx)
+			if err != nil && ctx.Err() == nil {
+				dsdk.logger().ErrorContext(ctx, "Error purging data flows", LogKeyError, err)
+			}
+			if purged > 0 {
+				dsdk.logger().InfoContext(ctx, "Purged data flows", "count", purged)
+			}
+		}
+	}
+}
+
+// countPerAgreement returns the number of flows in the given states per agreement. Flows without an agreement are not
+// counted. Flows are read in pages of the batch size.
+func (dsdk *DataPlaneSDK) countPerAgreement(ctx context.Context, states []DataFlowState) (map[string]int, error) {
+	counts := make(map[string]int)
+	pageSize := dsdk.purgeBatchSize()
+	query := DataFlowQuery{States: states, Limit: pageSize}
+	for {
+		var read int
+		err := dsdk.execute(ctx, func(ctx context.Context) error {
+			flows, err := dsdk.queryPage(ctx, query)
+			read = len(flows)
+			for _, flow := range flows {
+				if flow.AgreementID != "" {
+					counts[flow.AgreementID]++
+				}
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if read < pageSize {
+			return counts, nil
+		}
+		query.Offset += read
+	}
+}
+
+// purgeMatching removes the flows matching the query one batch at a time, oldest first. A positive limit bounds the
+// number of removed flows. Removed flows no longer match the query, so the next batch is read from the start, skipping
+// only the flows of failed batches.
+func (dsdk *DataPlaneSDK) purgeMatching(ctx context.Context, query DataFlowQuery, limit int) (int, error) {
+	pageSize := dsdk.purgeBatchSize()
+	purged := 0
+	var errs []error
+	for limit <= 0 || purged < limit {
+		query.Limit = pageSize
+		if limit > 0 && limit-purged < pageSize {
+			query.Limit = limit - purged
+		}
+		read, deleted, err := dsdk.purgeBatch(ctx, query)
+		purged += deleted
+		if err != nil {
+			dsdk.logger().ErrorContext(ctx, "Error purging data flows", LogKeyError, err)
+			errs = append(errs, err)
+			if read == 0 {
+				break // the batch could not be read
+			}
+		}
+		if read < query.Limit {
+			break
+		}
+		// flows that were read but not removed still match the query
+		query.Offset += read - deleted
+	}
+	return purged, errors.Join(errs...)
+}
+
+// purgeBatch reads a page of flows, passes them to the archiver and removes them in one transaction. It returns the
+// number of read and removed flows; if the archiver fails, the flows of the batch are kept.
+func (dsdk *DataPlaneSDK) purgeBatch(ctx context.Context, query DataFlowQuery) (int, int, error) {
+	read, deleted := 0, 0
+	err := dsdk.execute(ctx, func(ctx context.Context) error {
+		flows, err := dsdk.queryPage(ctx, query)
+		if err != nil {
+			return err
+		}
+		read = len(flows)
+		ids := make([]string, 0, len(flows))
+		for _, flow := range flows {
+			if dsdk.archiver != nil {
+				if err := dsdk.archiver(ctx, flow); err != nil {
+					return fmt.Errorf("archiving data flow %s: %w", flow.ID, err)
+				}
+			}
+			ids = append(ids, flow.ID)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		deleted, err = dsdk.Store.DeleteAll(ctx, ids)
+		return err
+	})
+	if err != nil {
+		return read, 0, fmt.Errorf("purging data flows: %w", err)
+	}
+	return read, deleted, nil
+}
+
+// queryPage reads the flows matching the query. The iterator is drained before the flows are processed, so that no
+// cursor is open during further store operations.
+func (dsdk *DataPlaneSDK) queryPage(ctx context.Context, query DataFlowQuery) ([]*DataFlow, error) {
+	iterator, err := dsdk.Store.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	var flows []*DataFlow
+	for iterator.Next() {
+		flows = append(flows, iterator.Get())
+	}
+	return flows, iterator.Error()
+}
+
+func (dsdk *DataPlaneSDK) purgeBatchSize() int {
+	if dsdk.retention.BatchSize <= 0 {
+		return DefaultPurgeBatchSize
+	}
+	return dsdk.retention.BatchSize
+}
+
+func (dsdk *DataPlaneSDK) retentionStates() []DataFlowState {
+	if len(dsdk.retention.States) == 0 {
+		return []DataFlowState{Completed, Terminated}
+	}
+	return dsdk.retention.States
+}
@@ -0,0 +1,138 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DataPlaneSDK_PurgeFlows_MaxAge(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	var archived []string
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		retention:  &RetentionPolicy{MaxAge: time.Hour, BatchSize: 2},
+		archiver: func(_ context.Context, flow *DataFlow) error {
+			archived = append(archived, flow.ID)
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+	old := time.Now().Add(-2 * time.Hour).UnixMilli()
+	page := func(offset int) any {
+		return mock.MatchedBy(func(q DataFlowQuery) bool {
+			return assert.ObjectsAreEqual([]DataFlowState{Completed, Terminated}, q.States) && q.UpdatedBefore > old &&
+				q.Offset == offset && q.Limit == 2
+		})
+	}
+	// each page is removed before the next one is read, so the next page starts at the same offset
+	store.EXPECT().Query(ctx, page(0)).Return(newSliceIterator(
+		&DataFlow{ID: "flow1", State: Completed, UpdatedAt: old},
+		&DataFlow{ID: "flow2", State: Terminated, UpdatedAt: old},
+	), nil).Once()
+	store.EXPECT().DeleteAll(ctx, []string{"flow1", "flow2"}).Return(2, nil).Once()
+	store.EXPECT().Query(ctx, page(0)).Return(newSliceIterator(&DataFlow{ID: "flow3", State: Completed, UpdatedAt: old}), nil).Once()
+	store.EXPECT().DeleteAll(ctx, []string{"flow3"}).Return(1, nil).Once()
+
+	purged, err := dsdk.PurgeFlows(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, purged)
+	assert.Equal(t, []string{"flow1", "flow2", "flow3"}, archived)
+}
+
+func Test_DataPlaneSDK_PurgeFlows_KeepPerAgreement(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		retention:  &RetentionPolicy{KeepPerAgreement: 1, BatchSize: 1},
+	}
+
+	ctx := context.Background()
+	states := []DataFlowState{Completed, Terminated}
+	// flows are counted per agreement one page at a time
+	for offset, flow := range []*DataFlow{
+		{ID: "a1-first", AgreementID: "a1"},
+		{ID: "a2-only", AgreementID: "a2"},
+		{ID: "a1-second", AgreementID: "a1"},
+		{ID: "a1-latest", AgreementID: "a1"},
+		{ID: "no-agreement"},
+	} {
+		store.EXPECT().Query(ctx, DataFlowQuery{States: states, Offset: offset, Limit: 1}).Return(newSliceIterator(flow), nil).Once()
+	}
+	store.EXPECT().Query(ctx, DataFlowQuery{States: states, Offset: 5, Limit: 1}).Return(newSliceIterator(), nil).Once()
+	// then the oldest flows exceeding the limit are removed one batch at a time
+	for _, id := range []string{"a1-first", "a1-second"} {
+		store.EXPECT().Query(ctx, DataFlowQuery{States: states, AgreementID: "a1", Limit: 1}).
+			Return(newSliceIterator(&DataFlow{ID: id, AgreementID: "a1"}), nil).Once()
+		store.EXPECT().DeleteAll(ctx, []string{id}).Return(1, nil).Once()
+	}
+
+	purged, err := dsdk.PurgeFlows(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+}
+
+func Test_DataPlaneSDK_PurgeFlows_ArchiveFails(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:      store,
+		TrxContext: &mockTrxContext{},
+		retention:  &RetentionPolicy{MaxAge: time.Hour, BatchSize: 1},
+		archiver: func(_ context.Context, flow *DataFlow) error {
+			if flow.ID == "flow1" {
+				return errors.New("bucket unavailable")
+			}
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+	old := time.Now().Add(-2 * time.Hour).UnixMilli()
+	offset := func(offset int) any {
+		return mock.MatchedBy(func(q DataFlowQuery) bool { return q.Offset == offset })
+	}
+	// the failed batch is kept and skipped
+	store.EXPECT().Query(ctx, offset(0)).Return(newSliceIterator(&DataFlow{ID: "flow1", UpdatedAt: old}), nil).Once()
+	store.EXPECT().Query(ctx, offset(1)).Return(newSliceIterator(&DataFlow{ID: "flow2", UpdatedAt: old}), nil).Once()
+	store.EXPECT().DeleteAll(ctx, []string{"flow2"}).Return(1, nil).Once()
+	store.EXPECT().Query(ctx, offset(1)).Return(newSliceIterator(), nil).Once()
+
+	purged, err := dsdk.PurgeFlows(ctx)
+
+	assert.ErrorContains(t, err, "bucket unavailable")
+	assert.Equal(t, 1, purged)
+}
+
+func Test_DataPlaneSDK_PurgeFlows_Disabled(t *testing.T) {
+	dsdk := DataPlaneSDK{Store: NewMockDataplaneStore(t), TrxContext: &mockTrxContext{}}
+
+	purged, err := dsdk.PurgeFlows(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+}
+
+func Test_DataPlaneSDKBuilder_RetentionPolicy(t *testing.T) {
+	builder := func() *DataPlaneSDKBuilder {
+		return NewDataPlaneSDKBuilder().Store(NewMockDataplaneStore(t)).TransactionContext(&mockTrxContext{})
+	}
+
+	_, err := builder().RetentionPolicy(RetentionPolicy{MaxAge: 30 * 24 * time.Hour, States: []DataFlowState{Terminated}}).Build()
+	assert.NoError(t, err)
+
+	_, err = builder().RetentionPolicy(RetentionPolicy{}).Build()
+	assert.ErrorContains(t, err, "requires a maximum age")
+
+	_, err = builder().RetentionPolicy(RetentionPolicy{KeepPerAgreement: 5, States: []DataFlowState{Started}}).Build()
+	assert.ErrorContains(t, err, "cannot remove flows in state STARTED")
+}
@@ -0,0 +1,106 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const idParam = "id"
+
+// SignalingHandlerOptions configures the handler returned by NewSignalingHandler.
+type SignalingHandlerOptions struct {
+	// BasePath is prepended to all routes, e.g. "/api/signaling". Defaults to the root path.
+	BasePath string
+	// Versions are path segments under which the routes are exposed, e.g. "v1" results in
+	// "<BasePath>/v1/dataflows/start". If empty, the routes are exposed directly under the base path.
+	Versions []string
+	// Authenticator authenticates every request before it reaches the API. Unauthenticated requests receive a 401
+	// response. If nil, requests are not authenticated.
+	Authenticator Authenticator
+}
+
+// NewSignalingHandler returns an http.Handler that exposes all Data Plane Signaling endpoints of the given API:
+//
+//	POST <prefix>/dataflows/prepare
+//	POST <prefix>/dataflows/start
+//	POST <prefix>/dataflows/{id}/start
+//	POST <prefix>/dataflows/{id}/suspend
+//	POST <prefix>/dataflows/{id}/terminate
+//	POST <prefix>/dataflows/{id}/completed
+//	GET  <prefix>/dataflows/{id}/status
+//	GET  <prefix>/dataflows/{id}/history
+//
+// Requests with an unsupported method receive a 405 problem with an Allow header. Failed requests receive RFC 7807
+// problem details, see Problem, and every response carries the correlation ID of the request in the
+// CorrelationIDHeader. If an Authenticator is configured, the authenticated Principal is available to the SDK and its
+// processors through PrincipalFromContext.
+func NewSignalingHandler(api *DataPlaneApi, options SignalingHandlerOptions) http.Handler {
+	router := chi.NewRouter()
+	router.Use(correlate, instrumentRequests(api.sdk.tracer, api.sdk.metrics))
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		api.writeProblem(r.Context(), w, &Problem{Type: ProblemTypeNotFound, Title: "Not found", Status: http.StatusNotFound,
+			Detail: "No signaling endpoint exists at the requested path"})
+	})
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		api.methodNotAllowed(r.Context(), w, allowedMethods(router, r.URL.Path)...)
+	})
+
+	routes := func(r chi.Router) {
+		if options.Authenticator != nil {
+			r.Use(func(next http.Handler) http.Handler {
+				return authenticate(options.Authenticator, next)
+			})
+		}
+		r.Post("/dataflows/prepare", api.Prepare)
+		r.Post("/dataflows/start", api.Start)
+		r.Post("/dataflows/{id}/start", withID(api.StartById))
+		r.Post("/dataflows/{id}/suspend", withID(api.Suspend))
+		r.Post("/dataflows/{id}/terminate", withID(api.Terminate))
+		r.Post("/dataflows/{id}/completed", withID(api.Complete))
+		r.Get("/dataflows/{id}/status", withID(api.Status))
+		r.Get("/dataflows/{id}/history", withID(api.History))
+	}
+
+	basePath := "/" + strings.Trim(options.BasePath, "/")
+	if len(options.Versions) == 0 {
+		router.Route(basePath, routes)
+		return router
+	}
+	for _, version := range options.Versions {
+		router.Route(strings.TrimSuffix(basePath, "/")+"/"+strings.Trim(version, "/"), routes)
+	}
+	return router
+}
+
+// allowedMethods returns the methods of the signaling routes that the router supports for the path. chi only sets the
+// Allow header in its default 405 handler, so the problem handler determines the methods itself.
+func allowedMethods(router chi.Router, path string) []string {
+	var allowed []string
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		if router.Match(chi.NewRouteContext(), method, path) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// withID adapts a handler that operates on a data flow to the chi router by extracting the flow ID from the path.
+func withID(handler func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, chi.URLParam(r, idParam))
+	}
+}
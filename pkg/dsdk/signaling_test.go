@@ -0,0 +1,220 @@
+package dsdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignalingHandler_Status(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var status DataFlowStatusResponseMessage
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+	assert.Equal(t, "flow123", status.DataFlowID)
+	assert.Equal(t, Started, status.State)
+}
+
+func Test_SignalingHandler_Status_DataAddresses(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{
+		ID:                     "flow123",
+		State:                  Started,
+		SourceDataAddress:      DataAddress{Properties: map[string]any{"name": "source"}},
+		DestinationDataAddress: DataAddress{Properties: map[string]any{"name": "destination"}},
+	}, nil)
+	handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var status DataFlowStatusResponseMessage
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+	require.NotNil(t, status.SourceDataAddress)
+	require.NotNil(t, status.DestinationDataAddress)
+	assert.Equal(t, "source", status.SourceDataAddress.Properties["name"])
+	assert.Equal(t, "destination", status.DestinationDataAddress.Properties["name"])
+}
+
+func Test_SignalingHandler_History(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().History(mock.Anything, "flow123").Return([]StateTransition{
+		{ProcessID: "flow123", FromState: Started, ToState: Suspended, Reason: "maintenance", MessageID: "msg1"},
+		{ProcessID: "flow123", FromState: Suspended, ToState: Terminated, Reason: "cancelled"},
+	}, nil)
+	handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/history", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var history DataFlowHistoryResponseMessage
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&history))
+	assert.Equal(t, "flow123", history.DataFlowID)
+	require.Len(t, history.Transitions, 2)
+	assert.Equal(t, "maintenance", history.Transitions[0].Reason)
+	assert.Equal(t, "msg1", history.Transitions[0].MessageID)
+	assert.Equal(t, Terminated, history.Transitions[1].ToState)
+}
+
+func Test_SignalingHandler_History_NotFound(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().History(mock.Anything, "flow123").Return(nil, nil)
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(nil, ErrNotFound)
+	handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/history", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func Test_SignalingHandler_Terminate_RecordsMessageID(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	store.EXPECT().Save(mock.MatchedBy(func(ctx context.Context) bool {
+		messageID, _ := MessageIDFromContext(ctx)
+		return messageID == "msg1"
+	}), mock.Anything).Return(nil)
+	handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+
+	body, _ := json.Marshal(DataFlowTransitionMessage{MessageID: "msg1", Reason: "cancelled"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dataflows/flow123/terminate", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func Test_SignalingHandler_History_RecordsMessageID(t *testing.T) {
+	tests := map[string]struct {
+		state DataFlowState
+		path  string
+		body  any
+	}{
+		"start by id": {Suspended, "/dataflows/flow123/start", DataFlowStartByIdMessage{
+			MessageID:         "msg1",
+			SourceDataAddress: &DataAddress{Properties: map[string]any{"endpoint": "https://example.com"}},
+		}},
+		"complete": {Started, "/dataflows/flow123/completed", DataFlowCompletionMessage{MessageID: "msg1"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := NewMockDataplaneStore(t)
+			store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: test.state}, nil)
+			var transitions []StateTransition
+			store.EXPECT().Save(mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				transitions = append(transitions, TransitionsToPersist(args.Get(0).(context.Context), args.Get(1).(*DataFlow))...)
+			}).Return(nil)
+			handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{})
+
+			body, _ := json.Marshal(test.body)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, test.path, bytes.NewReader(body)))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			require.NotEmpty(t, transitions)
+			for _, transition := range transitions {
+				assert.Equal(t, "msg1", transition.MessageID)
+			}
+		})
+	}
+}
+
+func Test_SignalingHandler_BasePathAndVersions(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+	handler := NewSignalingHandler(newSignalingApi(store, nil), SignalingHandlerOptions{
+		BasePath: "/api/signaling/",
+		Versions: []string{"v1", "v2"},
+	})
+
+	for _, path := range []string{"/api/signaling/v1/dataflows/flow123/status", "/api/signaling/v2/dataflows/flow123/status"} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func Test_SignalingHandler_MethodNotAllowed(t *testing.T) {
+	handler := NewSignalingHandler(newSignalingApi(NewMockDataplaneStore(t), nil), SignalingHandlerOptions{})
+
+	tests := map[string]struct {
+		method  string
+		path    string
+		allowed string
+	}{
+		"get start":       {http.MethodGet, "/dataflows/start", http.MethodPost},
+		"put prepare":     {http.MethodPut, "/dataflows/prepare", http.MethodPost},
+		"get terminate":   {http.MethodGet, "/dataflows/flow123/terminate", http.MethodPost},
+		"delete complete": {http.MethodDelete, "/dataflows/flow123/completed", http.MethodPost},
+		"post status":     {http.MethodPost, "/dataflows/flow123/status", http.MethodGet},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(test.method, test.path, nil))
+			problem := decodeProblem(t, rr, http.StatusMethodNotAllowed)
+			assert.Equal(t, test.allowed, rr.Header().Get("Allow"))
+			assert.Equal(t, ProblemTypeMethodNotAllowed, problem.Type)
+			assert.Equal(t, http.StatusMethodNotAllowed, problem.Status)
+		})
+	}
+
+	t.Run("versioned path", func(t *testing.T) {
+		versioned := NewSignalingHandler(newSignalingApi(NewMockDataplaneStore(t), nil),
+			SignalingHandlerOptions{BasePath: "/api/signaling", Versions: []string{"v1"}})
+		rr := httptest.NewRecorder()
+		versioned.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/signaling/v1/dataflows/flow123/terminate", nil))
+		decodeProblem(t, rr, http.StatusMethodNotAllowed)
+		assert.Equal(t, http.MethodPost, rr.Header().Get("Allow"))
+	})
+}
+
+func Test_SignalingHandler_StartingLocation(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	store.EXPECT().FindById(mock.Anything, "process123").Return(nil, ErrNotFound)
+	store.EXPECT().Create(mock.Anything, mock.AnythingOfType("*dsdk.DataFlow")).Return(nil)
+	onStart := func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+		return &DataFlowResponseMessage{State: Starting}, nil
+	}
+	handler := NewSignalingHandler(newSignalingApi(store, onStart), SignalingHandlerOptions{BasePath: "/signaling", Versions: []string{"v1"}})
+
+	payload, err := json.Marshal(createStartMessage())
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/signaling/v1/dataflows/start", bytes.NewReader(payload)))
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Equal(t, "/signaling/v1/dataflows/process123", rr.Header().Get("Location"))
+}
+
+func newSignalingApi(store DataplaneStore, onStart DataFlowProcessor) *DataPlaneApi {
+	sdk, err := NewDataPlaneSDKBuilder().
+		Store(store).
+		TransactionContext(&mockTrxContext{}).
+		OnStart(onStart).
+		Build()
+	if err != nil {
+		panic(err)
+	}
+	return NewDataPlaneApi(sdk)
+}
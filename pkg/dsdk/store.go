@@ -2,17 +2,148 @@ package dsdk
 
 import (
 	"context"
+	"slices"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@latest --name=DataplaneStore --output=. --outpkg=dsdk --filename=mock_dataplane_store_test.go --structname=MockDataplaneStore --with-expecter --inpackage
+//go:generate go run github.com/vektra/mockery/v2@latest --name=IdempotencyStore --output=. --outpkg=dsdk --filename=mock_idempotency_store_test.go --structname=MockIdempotencyStore --with-expecter --inpackage
 
 // DataplaneStore defines the extension point for finding, creating, saving, and iterating over DataFlow entities.
 type DataplaneStore interface {
 	// FindById returns a DataFlow for the given id or an error.
 	FindById(context.Context, string) (*DataFlow, error)
 	Create(context.Context, *DataFlow) error
+	// Save updates an existing flow. It returns ErrNotFound if the flow does not exist and ErrConflict if it was modified
+	// since it was read.
 	Save(context.Context, *DataFlow) error
+	// Delete removes the flow and its history.
 	Delete(ctx context.Context, id string) error
+	// DeleteAll removes the flows with the given IDs and their histories and returns the number of removed flows.
+	// Unknown IDs are ignored.
+	DeleteAll(ctx context.Context, ids []string) (int, error)
+	// Query returns an iterator over the flows matching the query, ordered by creation time. Callers must close the
+	// iterator. Implementations may hold a database cursor open until the iterator is closed, so callers running inside
+	// a transaction should drain the iterator before issuing further store operations.
+	Query(ctx context.Context, query DataFlowQuery) (Iterator[*DataFlow], error)
+	// History returns the state transitions of the flow in the order they were applied. Create and Save append the
+	// pending transitions of a flow (see TransitionsToPersist) in the same transaction that persists the flow. The
+	// history is append-only while the flow exists; Delete and DeleteAll remove it together with the flow, so it is
+	// bounded by the retention policy.
+	History(ctx context.Context, processID string) ([]StateTransition, error)
+	// RenewLeases sets the lease expiry of all non-terminal flows owned by the runtime to the given epoch millis and
+	// returns the number of renewed flows. Renewing a lease does not change the flow version.
+	RenewLeases(ctx context.Context, runtimeID string, expiry int64) (int, error)
+	// CountFlows returns the number of flows per state and transfer type. Combinations without flows are omitted.
+	CountFlows(ctx context.Context) ([]FlowCount, error)
+}
+
+// FlowCount is the number of flows in a state with a transfer type, see DataplaneStore.CountFlows.
+type FlowCount struct {
+	State        DataFlowState
+	TransferType TransferType
+	Count        int
+}
+
+// DataFlowQuery contains the criteria for DataplaneStore.Query. Zero values are ignored, so an empty query matches all
+// flows.
+type DataFlowQuery struct {
+	// States matches flows in any of the given states.
+	States         []DataFlowState
+	ParticipantID  string
+	CounterPartyID string
+	AgreementID    string
+	DatasetID      string
+	// DestinationType and FlowType match the respective TransferType fields.
+	DestinationType string
+	FlowType        FlowType
+	// UpdatedAfter matches flows updated at or after the given epoch millis.
+	UpdatedAfter int64
+	// UpdatedBefore matches flows updated before the given epoch millis.
+	UpdatedBefore int64
+	// RuntimeID matches flows owned by the given runtime.
+	RuntimeID string
+	// LeaseExpiredBefore matches flows whose lease expires before the given epoch millis. Flows without a lease (zero
+	// LeaseExpiry) are not matched.
+	LeaseExpiredBefore int64
+	// Offset is the number of matching flows to skip.
+	Offset int
+	// Limit is the maximum number of flows to return. Zero means no limit.
+	Limit int
+}
+
+// Matches returns true if the flow satisfies the filter criteria of the query. Paging is not considered.
+func (q DataFlowQuery) Matches(flow *DataFlow) bool {
+	if len(q.States) > 0 && !slices.Contains(q.States, flow.State) {
+		return false
+	}
+	switch {
+	case q.ParticipantID != "" && q.ParticipantID != flow.ParticipantID,
+		q.CounterPartyID != "" && q.CounterPartyID != flow.CounterPartyID,
+		q.AgreementID != "" && q.AgreementID != flow.AgreementID,
+		q.DatasetID != "" && q.DatasetID != flow.DatasetID,
+		q.DestinationType != "" && q.DestinationType != flow.TransferType.DestinationType,
+		q.FlowType != "" && q.FlowType != flow.TransferType.FlowType,
+		q.UpdatedAfter != 0 && flow.UpdatedAt < q.UpdatedAfter,
+		q.UpdatedBefore != 0 && flow.UpdatedAt >= q.UpdatedBefore,
+		q.RuntimeID != "" && q.RuntimeID != flow.RuntimeID,
+		q.LeaseExpiredBefore != 0 && (flow.LeaseExpiry == 0 || flow.LeaseExpiry >= q.LeaseExpiredBefore):
+		return false
+	}
+	return true
+}
+
+// IdempotencyRecord is the stored outcome of a processed signaling message.
+type IdempotencyRecord struct {
+	MessageID string
+	ProcessID string
+	Response  DataFlowResponseMessage
+	// CreatedAt is the time the message was processed in epoch millis.
+	CreatedAt int64
+}
+
+// IdempotencyStore defines the extension point for persisting the responses of processed messages, keyed by message
+// ID, so that replayed messages can be answered without processing them again.
+type IdempotencyStore interface {
+	// FindByMessageId returns the record for the given message ID or ErrNotFound.
+	FindByMessageId(ctx context.Context, messageID string) (*IdempotencyRecord, error)
+	// Save creates the record or replaces an existing record with the same message ID.
+	Save(ctx context.Context, record *IdempotencyRecord) error
+	// DeleteExpired removes all records created before the given epoch millis and returns the number of removed records.
+	DeleteExpired(ctx context.Context, before int64) (int, error)
+}
+
+// OutboxEntry is a control plane notification written in the transaction that persists a state change. Entries are
+// delivered by the outbox relay, see DataPlaneSDK.RelayOutbox.
+type OutboxEntry struct {
+	// ID is assigned by the store when the entry is appended and orders the entries of a flow.
+	ID        int64
+	ProcessID string
+	// Flow is a snapshot of the flow at the time of the state change.
+	Flow        DataFlow
+	DataAddress *DataAddress
+	// Attempts is the number of failed deliveries.
+	Attempts int
+	// NextAttemptAt is the earliest time of the next delivery in epoch millis.
+	NextAttemptAt int64
+	LastError     string
+	// CreatedAt is the time the entry was appended in epoch millis.
+	CreatedAt int64
+}
+
+// OutboxStore defines the extension point for persisting outgoing notifications together with the flow, so that they
+// are neither lost nor sent for state changes that were rolled back.
+type OutboxStore interface {
+	// Append adds the entry and assigns its ID. It is called in the transaction that persists the state change.
+	Append(ctx context.Context, entry *OutboxEntry) error
+	// Claim reserves up to limit entries for delivery until the given epoch millis and returns them ordered by ID. Only
+	// the oldest entry of each flow is eligible, and only if it is due at now and not reserved by another claim, so the
+	// entries of a flow are delivered one at a time and in order.
+	Claim(ctx context.Context, now int64, until int64, limit int) ([]OutboxEntry, error)
+	// Delete removes a delivered entry.
+	Delete(ctx context.Context, id int64) error
+	// Reschedule records a failed delivery: it increments the attempts, sets the next attempt and the error, and releases
+	// the claim.
+	Reschedule(ctx context.Context, id int64, nextAttemptAt int64, lastError string) error
 }
 
 // TransactionContext defines an extension point for executing operations within a transactional context.
@@ -0,0 +1,161 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName identifies the tracer of the SDK.
+const instrumentationName = "github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+
+// Span attributes recorded for data flows.
+const (
+	AttributeFlowID          = attribute.Key("dataflow.id")
+	AttributeStateBefore     = attribute.Key("dataflow.state.before")
+	AttributeStateAfter      = attribute.Key("dataflow.state.after")
+	AttributeDestinationType = attribute.Key("dataflow.transfer_type.destination")
+	AttributeFlowType        = attribute.Key("dataflow.transfer_type.flow")
+)
+
+// startSpan starts a span if tracing is enabled. Otherwise, the context is returned unchanged with a no-op span.
+func (dsdk *DataPlaneSDK) startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, dsdk.tracer, name, attributes...)
+}
+
+func startSpan(ctx context.Context, tracer trace.Tracer, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if tracer == nil {
+		return ctx, noop.Span{}
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// endSpan records the error, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// recordFlow adds the attributes of the flow to the current span.
+func recordFlow(ctx context.Context, flow *DataFlow, before DataFlowState) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() || flow == nil {
+		return
+	}
+	span.SetAttributes(flowAttributes(flow, before)...)
+}
+
+func flowAttributes(flow *DataFlow, before DataFlowState) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttributeFlowID.String(flow.ID),
+		AttributeStateBefore.String(before.String()),
+		AttributeStateAfter.String(flow.State.String()),
+		AttributeDestinationType.String(flow.TransferType.DestinationType),
+		AttributeFlowType.String(string(flow.TransferType.FlowType)),
+	}
+}
+
+// stateBefore returns the state of the flow before its pending transitions.
+func stateBefore(flow *DataFlow) DataFlowState {
+	if pending := flow.PendingTransitions(); len(pending) > 0 {
+		return pending[0].FromState
+	}
+	return flow.State
+}
+
+// tracingInterceptor records a span for each processor and handler invocation. It is registered innermost by the
+// builder when a TracerProvider is configured.
+func tracingInterceptor(tracer trace.Tracer) Interceptor {
+	return func(ctx context.Context, invocation Invocation, next Invoker) (*DataFlowResponseMessage, error) {
+		name := "DataFlowHandler." + string(invocation.Operation)
+		if invocation.Options != nil {
+			name = "DataFlowProcessor." + string(invocation.Operation)
+		}
+		ctx, span := tracer.Start(ctx, name)
+		var before DataFlowState
+		if invocation.Flow != nil {
+			before = invocation.Flow.State
+		}
+
+		response, err := next(ctx, invocation)
+		if invocation.Flow != nil {
+			span.SetAttributes(flowAttributes(invocation.Flow, before)...)
+		}
+		if response != nil {
+			span.SetAttributes(AttributeStateAfter.String(response.State.String()))
+		}
+		endSpan(span, err)
+		return response, err
+	}
+}
+
+// instrumentRequests extracts the W3C trace context of incoming signaling requests so that SDK spans continue the trace
+// of the control plane. If a tracer is given, a server span is recorded for each request. If metrics are given, requests
+// are counted by operation and status code.
+func instrumentRequests(tracer trace.Tracer, metrics *Metrics) func(http.Handler) http.Handler {
+	propagator := propagation.TraceContext{}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			if tracer == nil && metrics == nil {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			span := trace.SpanFromContext(ctx)
+			if tracer != nil {
+				ctx, span = tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer),
+					trace.WithAttributes(semconv.HTTPRequestMethodKey.String(r.Method), semconv.URLPath(r.URL.Path)))
+				defer span.End()
+			}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			var pattern string
+			if routeContext := chi.RouteContext(ctx); routeContext != nil {
+				pattern = routeContext.RoutePattern()
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if metrics != nil {
+				metrics.observeRequest(signalingOperation(pattern), status)
+			}
+			if tracer == nil {
+				return
+			}
+			if pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(semconv.HTTPRoute(pattern))
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package dsdk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func Test_Tracing_Operation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	store := NewMockDataplaneStore(t)
+	sdk := newTracedSDK(t, store, recorder)
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{
+		ID:           "flow123",
+		State:        Started,
+		TransferType: TransferType{DestinationType: "HttpData", FlowType: Pull},
+	}, nil)
+	store.EXPECT().Save(mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, sdk.Terminate(context.Background(), "flow123", "cancelled"))
+
+	spans := spansByName(recorder)
+	require.Len(t, spans, 4)
+	operation := spans["DataPlaneSDK.Terminate"]
+	require.NotNil(t, operation)
+	for _, name := range []string{"DataplaneStore.FindById", "DataFlowHandler.terminate", "DataplaneStore.Save"} {
+		require.Contains(t, spans, name)
+		assert.Equal(t, operation.SpanContext().SpanID(), spans[name].Parent().SpanID(), name)
+	}
+	assert.Subset(t, operation.Attributes(), []attribute.KeyValue{
+		AttributeFlowID.String("flow123"),
+		AttributeStateBefore.String("STARTED"),
+		AttributeStateAfter.String("TERMINATED"),
+		AttributeDestinationType.String("HttpData"),
+		AttributeFlowType.String(string(Pull)),
+	})
+	assert.Subset(t, spans["DataFlowHandler.terminate"].Attributes(), []attribute.KeyValue{
+		AttributeStateBefore.String("STARTED"),
+	})
+}
+
+func Test_Tracing_OperationError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	store := NewMockDataplaneStore(t)
+	sdk := newTracedSDK(t, store, recorder)
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(nil, ErrNotFound)
+
+	assert.Error(t, sdk.Complete(context.Background(), "flow123"))
+
+	spans := spansByName(recorder)
+	assert.Equal(t, codes.Error, spans["DataPlaneSDK.Complete"].Status().Code)
+	assert.Equal(t, codes.Error, spans["DataplaneStore.FindById"].Status().Code)
+}
+
+func Test_Tracing_SignalingContinuesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	store := NewMockDataplaneStore(t)
+	sdk := newTracedSDK(t, store, recorder)
+	handler := NewSignalingHandler(NewDataPlaneApi(sdk), SignalingHandlerOptions{})
+
+	store.EXPECT().FindById(mock.Anything, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/dataflows/flow123/status", nil)
+	req.Header.Set("traceparent", traceParent)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	spans := spansByName(recorder)
+	server := spans["GET /dataflows/{id}/status"]
+	require.NotNil(t, server)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.True(t, server.Parent().IsRemote())
+	assert.Equal(t, server.SpanContext().SpanID(), spans["DataPlaneSDK.Status"].Parent().SpanID())
+	assert.Contains(t, server.Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+}
+
+func Test_Tracing_Disabled(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	sdk, err := NewDataPlaneSDKBuilder().Store(store).TransactionContext(&mockTrxContext{}).Build()
+	require.NoError(t, err)
+
+	assert.Same(t, store, sdk.Store)
+	ctx := context.Background()
+	ctx2, span := sdk.startSpan(ctx, "noop")
+	assert.Equal(t, ctx, ctx2)
+	assert.False(t, span.IsRecording())
+}
+
+func Test_CallbackClient_PropagatesTraceContext(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client, err := NewCallbackClientBuilder().Build()
+	require.NoError(t, err)
+	callbackURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "operation")
+	defer span.End()
+
+	require.NoError(t, client.Notify(ctx, &DataFlow{ID: "flow123", CallbackAddress: CallbackURL(*callbackURL)}, nil))
+
+	assert.Contains(t, received, span.SpanContext().TraceID().String())
+}
+
+func newTracedSDK(t *testing.T, store DataplaneStore, recorder *tracetest.SpanRecorder) *DataPlaneSDK {
+	sdk, err := NewDataPlaneSDKBuilder().
+		Store(store).
+		TransactionContext(&mockTrxContext{}).
+		TracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))).
+		Build()
+	require.NoError(t, err)
+	return sdk
+}
+
+func spansByName(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	return spans
+}
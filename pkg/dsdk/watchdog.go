@@ -0,0 +1,171 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StateTimeout configures how long a flow may remain in PREPARING or STARTING before the watchdog acts on it.
+type StateTimeout struct {
+	// Timeout is the time a flow may remain in the state before the processor is invoked again.
+	Timeout time.Duration
+	// MaxAttempts is the number of processor invocations, including the initial one, after which a timed-out flow is
+	// terminated. Values below 2 terminate the flow on its first timeout.
+	MaxAttempts int
+}
+
+// watchableStates are the states in which a flow waits for its processor to report progress.
+var watchableStates = []DataFlowState{Preparing, Starting}
+
+// ReapStuckFlows handles flows owned by this runtime that have exceeded their state timeout. A flow with remaining
+// attempts is passed to its processor again (onPrepare or onStart with ProcessorOptions.Retry set); otherwise it is
+// terminated. Retries that change the state and terminations are reported to the control plane.
+func (dsdk *DataPlaneSDK) ReapStuckFlows(ctx context.Context) (err error) {
+	if len(dsdk.stateTimeouts) == 0 {
+		return nil
+	}
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.ReapStuckFlows")
+	defer func() { endSpan(span, err) }()
+	ids, err := dsdk.stuckFlows(ctx)
+	if err != nil {
+		return fmt.Errorf("finding stuck data flows: %w", err)
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := dsdk.reapFlow(ctx, id); err != nil {
+			dsdk.logger().ErrorContext(ctx, "Error handling stuck data flow", LogKeyFlowID, id, LogKeyError, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RunReaper calls ReapStuckFlows at the given interval until the context is cancelled.
+func (dsdk *DataPlaneSDK) RunReaper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := dsdk.ReapStuckFlows(ctx); err != nil && ctx.Err() == nil {
+				dsdk.logger().ErrorContext(ctx, "Error reaping stuck data flows", LogKeyError, err)
+			}
+		}
+	}
+}
+
+func (dsdk *DataPlaneSDK) stuckFlows(ctx context.Context) ([]string, error) {
+	states := make([]DataFlowState, 0, len(dsdk.stateTimeouts))
+	for state := range dsdk.stateTimeouts {
+		states = append(states, state)
+	}
+
+	var ids []string
+	err := dsdk.execute(ctx, func(ctx context.Context) error {
+		iterator, err := dsdk.Store.Query(ctx, DataFlowQuery{States: states, RuntimeID: dsdk.RuntimeID})
+		if err != nil {
+			return err
+		}
+		defer iterator.Close()
+		now := time.Now()
+		for iterator.Next() {
+			if flow := iterator.Get(); dsdk.isStuck(flow, now) {
+				ids = append(ids, flow.ID)
+			}
+		}
+		return iterator.Error()
+	})
+	return ids, err
+}
+
+func (dsdk *DataPlaneSDK) isStuck(flow *DataFlow, now time.Time) bool {
+	timeout, found := dsdk.stateTimeouts[flow.State]
+	return found && now.Sub(time.UnixMilli(flow.StateTimestamp)) > timeout.Timeout
+}
+
+func (dsdk *DataPlaneSDK) reapFlow(ctx context.Context, processID string) (err error) {
+	ctx, span := dsdk.startSpan(ctx, "DataPlaneSDK.ReapFlow", AttributeFlowID.String(processID))
+	defer func() { endSpan(span, err) }()
+	var flow *DataFlow
+	var dataAddress *DataAddress
+	changed := false
+	err = dsdk.execute(ctx, func(ctx context.Context) error {
+		found, err := dsdk.Store.FindById(ctx, processID)
+		if err != nil {
+			return fmt.Errorf("reaping data flow %s: %w", processID, err)
+		}
+		now := time.Now()
+		if !dsdk.isStuck(found, now) {
+			return nil // progressed since the flows were collected
+		}
+
+		previous := found.State
+		attempts := int(found.RetryCount) + 1
+		if attempts >= dsdk.stateTimeouts[found.State].MaxAttempts {
+			if err := dsdk.onTerminate(ctx, found); err != nil {
+				return fmt.Errorf("terminating data flow %s: %w", processID, err)
+			}
+			reason := fmt.Sprintf("timed out in %s after %d attempts", found.State, attempts)
+			if err := found.fail(Failure{Code: FailureCodeTimeout, Message: reason, Retryable: true}); err != nil {
+				return err
+			}
+		} else {
+			response, err := dsdk.retryProcessor(ctx, found)
+			if err != nil {
+				dsdk.logger().WarnContext(ctx, "Error retrying data flow", LogKeyFlowID, processID, LogKeyParticipantID, found.ParticipantID,
+					LogKeyCounterPartyID, found.CounterPartyID, "state", found.State.String(), LogKeyError, err)
+			} else if err := transitionTo(found, response); err != nil {
+				return fmt.Errorf("processor returned an invalid state: %w", err)
+			} else {
+				dataAddress = response.DataAddress
+			}
+			if found.State == previous {
+				// start a new timeout window for the retry
+				found.RetryCount++
+				found.StateTimestamp = now.UnixMilli()
+			}
+		}
+
+		if err := dsdk.save(ctx, found); err != nil {
+			return fmt.Errorf("reaping data flow %s: %w", processID, err)
+		}
+		flow = found
+		changed = found.State != previous
+		if !changed {
+			return nil
+		}
+		return dsdk.enqueueNotification(ctx, found, dataAddress)
+	})
+	if err != nil || !changed {
+		return err
+	}
+	return dsdk.notify(ctx, flow, dataAddress)
+}
+
+func (dsdk *DataPlaneSDK) retryProcessor(ctx context.Context, flow *DataFlow) (*DataFlowResponseMessage, error) {
+	switch flow.State {
+	case Preparing:
+		return dsdk.onPrepare(ctx, flow, dsdk, &ProcessorOptions{Retry: true})
+	case Starting:
+		return dsdk.onStart(ctx, flow, dsdk, &ProcessorOptions{Retry: true, SourceDataAddress: &flow.SourceDataAddress})
+	default:
+		return nil, fmt.Errorf("%w: cannot retry data flow in state %s", ErrInvalidTransition, flow.State)
+	}
+}
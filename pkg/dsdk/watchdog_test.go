@@ -0,0 +1,150 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DataPlaneSDK_ReapStuckFlows_RetriesProcessor(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	var options *ProcessorOptions
+	dsdk := DataPlaneSDK{
+		Store:         store,
+		TrxContext:    &mockTrxContext{},
+		Notifier:      notifier,
+		stateTimeouts: map[DataFlowState]StateTimeout{Starting: {Timeout: time.Minute, MaxAttempts: 3}},
+		onStart: func(_ context.Context, _ *DataFlow, _ *DataPlaneSDK, o *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			options = o
+			return &DataFlowResponseMessage{State: Started}, nil
+		},
+	}
+
+	ctx := context.Background()
+	expired := time.Now().Add(-2 * time.Minute).UnixMilli()
+	store.EXPECT().Query(ctx, DataFlowQuery{States: []DataFlowState{Starting}}).
+		Return(newSliceIterator(
+			&DataFlow{ID: "stuck", State: Starting, StateTimestamp: expired},
+			&DataFlow{ID: "recent", State: Starting, StateTimestamp: time.Now().UnixMilli()},
+		), nil)
+	store.EXPECT().FindById(ctx, "stuck").Return(&DataFlow{ID: "stuck", State: Starting, StateTimestamp: expired}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.ID == "stuck" && df.State == Started && df.RetryCount == 0
+	})).Return(nil)
+
+	err := dsdk.ReapStuckFlows(ctx)
+
+	require.NoError(t, err)
+	require.NotNil(t, options)
+	assert.True(t, options.Retry)
+	require.Len(t, notifier.flows, 1)
+	assert.Equal(t, Started, notifier.flows[0].State)
+}
+
+func Test_DataPlaneSDK_ReapStuckFlows_StartsNewTimeoutWindow(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	dsdk := DataPlaneSDK{
+		Store:         store,
+		TrxContext:    &mockTrxContext{},
+		Notifier:      notifier,
+		stateTimeouts: map[DataFlowState]StateTimeout{Preparing: {Timeout: time.Minute, MaxAttempts: 3}},
+		onPrepare: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			return nil, errors.New("provisioning failed")
+		},
+	}
+
+	ctx := context.Background()
+	expired := time.Now().Add(-2 * time.Minute).UnixMilli()
+	store.EXPECT().Query(ctx, mock.Anything).
+		Return(newSliceIterator(&DataFlow{ID: "flow123", State: Preparing, StateTimestamp: expired}), nil)
+	store.EXPECT().FindById(ctx, "flow123").
+		Return(&DataFlow{ID: "flow123", State: Preparing, StateTimestamp: expired, RetryCount: 1}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.State == Preparing && df.RetryCount == 2 && df.StateTimestamp > expired
+	})).Return(nil)
+
+	err := dsdk.ReapStuckFlows(ctx)
+
+	require.NoError(t, err)
+	assert.Empty(t, notifier.flows, "unchanged state must not be reported")
+}
+
+func Test_DataPlaneSDK_ReapStuckFlows_TerminatesAfterMaxAttempts(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	notifier := &mockNotifier{}
+	terminated := false
+	dsdk := DataPlaneSDK{
+		Store:         store,
+		TrxContext:    &mockTrxContext{},
+		Notifier:      notifier,
+		stateTimeouts: map[DataFlowState]StateTimeout{Starting: {Timeout: time.Minute, MaxAttempts: 3}},
+		onStart: func(context.Context, *DataFlow, *DataPlaneSDK, *ProcessorOptions) (*DataFlowResponseMessage, error) {
+			t.Fatal("onStart must not be invoked after the last attempt")
+			return nil, nil
+		},
+		onTerminate: func(context.Context, *DataFlow) error {
+			terminated = true
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+	expired := time.Now().Add(-2 * time.Minute).UnixMilli()
+	store.EXPECT().Query(ctx, mock.Anything).
+		Return(newSliceIterator(&DataFlow{ID: "flow123", State: Starting, StateTimestamp: expired}), nil)
+	store.EXPECT().FindById(ctx, "flow123").
+		Return(&DataFlow{ID: "flow123", State: Starting, StateTimestamp: expired, RetryCount: 2}, nil)
+	store.EXPECT().Save(ctx, mock.MatchedBy(func(df *DataFlow) bool {
+		return df.State == Terminated && df.ErrorDetail == "timed out in STARTING after 3 attempts"
+	})).Return(nil)
+
+	err := dsdk.ReapStuckFlows(ctx)
+
+	require.NoError(t, err)
+	assert.True(t, terminated)
+	require.Len(t, notifier.flows, 1)
+	assert.Equal(t, Terminated, notifier.flows[0].State)
+	assert.Equal(t, "timed out in STARTING after 3 attempts", notifier.flows[0].ErrorDetail)
+	assert.Equal(t, &Failure{Code: FailureCodeTimeout, Message: "timed out in STARTING after 3 attempts", Retryable: true,
+		Origin: FailureOriginLocal, Timestamp: notifier.flows[0].StateTimestamp}, notifier.flows[0].Failure)
+}
+
+func Test_DataPlaneSDK_ReapStuckFlows_SkipsProgressedFlows(t *testing.T) {
+	store := NewMockDataplaneStore(t)
+	dsdk := DataPlaneSDK{
+		Store:         store,
+		TrxContext:    &mockTrxContext{},
+		stateTimeouts: map[DataFlowState]StateTimeout{Starting: {Timeout: time.Minute}},
+	}
+
+	ctx := context.Background()
+	expired := time.Now().Add(-2 * time.Minute).UnixMilli()
+	store.EXPECT().Query(ctx, mock.Anything).
+		Return(newSliceIterator(&DataFlow{ID: "flow123", State: Starting, StateTimestamp: expired}), nil)
+	// started after the flows were collected
+	store.EXPECT().FindById(ctx, "flow123").Return(&DataFlow{ID: "flow123", State: Started}, nil)
+
+	assert.NoError(t, dsdk.ReapStuckFlows(ctx))
+}
+
+func Test_DataPlaneSDKBuilder_StateTimeout(t *testing.T) {
+	builder := func() *DataPlaneSDKBuilder {
+		return NewDataPlaneSDKBuilder().Store(NewMockDataplaneStore(t)).TransactionContext(&mockTrxContext{})
+	}
+
+	_, err := builder().StateTimeout(Starting, StateTimeout{Timeout: time.Minute, MaxAttempts: 2}).Build()
+	assert.NoError(t, err)
+
+	_, err = builder().StateTimeout(Started, StateTimeout{Timeout: time.Minute}).Build()
+	assert.ErrorContains(t, err, "not supported for STARTED")
+
+	_, err = builder().StateTimeout(Preparing, StateTimeout{}).Build()
+	assert.ErrorContains(t, err, "must be positive")
+}
@@ -0,0 +1,75 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+)
+
+// InMemoryIdempotencyStore is a thread-safe in-memory implementation of IdempotencyStore
+type InMemoryIdempotencyStore struct {
+	mu      sync.RWMutex
+	records map[string]*dsdk.IdempotencyRecord
+}
+
+// NewInMemoryIdempotencyStore creates a new thread-safe in-memory idempotency store
+func NewInMemoryIdempotencyStore() *InMemoryIdempotencyStore {
+	return &InMemoryIdempotencyStore{
+		records: make(map[string]*dsdk.IdempotencyRecord),
+	}
+}
+
+// FindByMessageId returns the record for the given message ID or an error
+func (s *InMemoryIdempotencyStore) FindByMessageId(ctx context.Context, messageID string) (*dsdk.IdempotencyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, exists := s.records[messageID]
+	if !exists {
+		return nil, dsdk.ErrNotFound
+	}
+	recordCopy := *record
+	return &recordCopy, nil
+}
+
+// Save creates or replaces the record for its message ID
+func (s *InMemoryIdempotencyStore) Save(ctx context.Context, record *dsdk.IdempotencyRecord) error {
+	if record == nil || record.MessageID == "" {
+		return dsdk.ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recordCopy := *record
+	s.records[record.MessageID] = &recordCopy
+	return nil
+}
+
+// DeleteExpired removes all records created before the given epoch millis
+func (s *InMemoryIdempotencyStore) DeleteExpired(ctx context.Context, before int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for id, record := range s.records {
+		if record.CreatedAt < before {
+			delete(s.records, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
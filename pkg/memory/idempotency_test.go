@@ -0,0 +1,66 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryIdempotencyStore_SaveAndFind(t *testing.T) {
+	store := NewInMemoryIdempotencyStore()
+	ctx := context.Background()
+
+	_, err := store.FindByMessageId(ctx, "message1")
+	assert.ErrorIs(t, err, dsdk.ErrNotFound)
+
+	record := &dsdk.IdempotencyRecord{
+		MessageID: "message1",
+		ProcessID: "process1",
+		Response:  dsdk.DataFlowResponseMessage{State: dsdk.Started},
+		CreatedAt: 100,
+	}
+	require.NoError(t, store.Save(ctx, record))
+
+	found, err := store.FindByMessageId(ctx, "message1")
+	require.NoError(t, err)
+	assert.Equal(t, record, found)
+
+	// modifications of the returned copy must not affect the store
+	found.ProcessID = "modified"
+	found, err = store.FindByMessageId(ctx, "message1")
+	require.NoError(t, err)
+	assert.Equal(t, "process1", found.ProcessID)
+
+	assert.ErrorIs(t, store.Save(ctx, &dsdk.IdempotencyRecord{}), dsdk.ErrInvalidInput)
+}
+
+func TestInMemoryIdempotencyStore_DeleteExpired(t *testing.T) {
+	store := NewInMemoryIdempotencyStore()
+	ctx := context.Background()
+	require.NoError(t, store.Save(ctx, &dsdk.IdempotencyRecord{MessageID: "old", CreatedAt: 100}))
+	require.NoError(t, store.Save(ctx, &dsdk.IdempotencyRecord{MessageID: "new", CreatedAt: 200}))
+
+	deleted, err := store.DeleteExpired(ctx, 200)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = store.FindByMessageId(ctx, "old")
+	assert.ErrorIs(t, err, dsdk.ErrNotFound)
+	_, err = store.FindByMessageId(ctx, "new")
+	assert.NoError(t, err)
+}
@@ -13,22 +13,29 @@
 package memory
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
 )
 
 // InMemoryStore is a thread-safe in-memory implementation of DataplaneStore
 type InMemoryStore struct {
-	mu    sync.RWMutex
-	flows map[string]*dsdk.DataFlow
+	mu      sync.RWMutex
+	flows   map[string]*dsdk.DataFlow
+	history map[string][]dsdk.StateTransition
 }
 
 // NewInMemoryStore creates a new thread-safe in-memory store
 func NewInMemoryStore() *InMemoryStore {
 	return &InMemoryStore{
-		flows: make(map[string]*dsdk.DataFlow),
+		flows:   make(map[string]*dsdk.DataFlow),
+		history: make(map[string][]dsdk.StateTransition),
 	}
 }
 
@@ -43,8 +50,7 @@ func (s *InMemoryStore) FindById(ctx context.Context, id string) (*dsdk.DataFlow
 	}
 
 	// Return a copy to prevent external modifications
-	flowCopy := *flow
-	return &flowCopy, nil
+	return copyFlow(flow), nil
 }
 
 // Create creates a new DataFlow entry
@@ -63,13 +69,21 @@ func (s *InMemoryStore) Create(ctx context.Context, flow *dsdk.DataFlow) error {
 		return dsdk.ErrConflict
 	}
 
-	// Store a copy to prevent external modifications
-	flowCopy := *flow
-	s.flows[flow.ID] = &flowCopy
+	// Timestamps set by the caller, e.g. by DataFlowBuilder, are kept
+	now := time.Now().UnixMilli()
+	if flow.CreatedAt == 0 {
+		flow.CreatedAt = now
+	}
+	if flow.UpdatedAt == 0 {
+		flow.UpdatedAt = now
+	}
+	s.store(ctx, flow)
 	return nil
 }
 
-// Save updates an existing DataFlow entry
+// Save updates an existing DataFlow entry. The update fails with dsdk.ErrConflict if the version of the given flow does
+// not match the stored version. On success, the version of the given flow is incremented and UpdatedAt is set to the
+// current time.
 func (s *InMemoryStore) Save(ctx context.Context, flow *dsdk.DataFlow) error {
 	if flow == nil {
 		return dsdk.ErrInvalidInput
@@ -81,17 +95,49 @@ func (s *InMemoryStore) Save(ctx context.Context, flow *dsdk.DataFlow) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.flows[flow.ID]; !exists {
+	stored, exists := s.flows[flow.ID]
+	if !exists {
 		return dsdk.ErrNotFound
 	}
 
-	// Store a copy to prevent external modifications
-	flowCopy := *flow
-	s.flows[flow.ID] = &flowCopy
+	// Optimistic locking: reject the update if the flow was modified since it was read
+	if stored.Version != flow.Version {
+		return fmt.Errorf("%w: data flow %s was modified concurrently (version %d, expected %d)", dsdk.ErrConflict, flow.ID, flow.Version, stored.Version)
+	}
+	flow.Version++
+	flow.UpdatedAt = time.Now().UnixMilli()
+
+	s.store(ctx, flow)
 	return nil
 }
 
-// Delete removes a DataFlow entry by id
+// store records the pending transitions of the flow and stores a copy to prevent external modifications. The caller
+// must hold the write lock.
+func (s *InMemoryStore) store(ctx context.Context, flow *dsdk.DataFlow) {
+	s.history[flow.ID] = append(s.history[flow.ID], dsdk.TransitionsToPersist(ctx, flow)...)
+	flow.ClearTransitions()
+	s.flows[flow.ID] = copyFlow(flow)
+}
+
+// copyFlow returns a copy of the flow that does not share its failure.
+func copyFlow(flow *dsdk.DataFlow) *dsdk.DataFlow {
+	flowCopy := *flow
+	if flow.Failure != nil {
+		failure := *flow.Failure
+		flowCopy.Failure = &failure
+	}
+	return &flowCopy
+}
+
+// History returns the recorded transitions of the flow
+func (s *InMemoryStore) History(ctx context.Context, processID string) ([]dsdk.StateTransition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.history[processID]), nil
+}
+
+// Delete removes a DataFlow entry and its history by id
 func (s *InMemoryStore) Delete(ctx context.Context, id string) error {
 	if id == "" {
 		return dsdk.ErrInvalidInput
@@ -105,9 +151,101 @@ func (s *InMemoryStore) Delete(ctx context.Context, id string) error {
 	}
 
 	delete(s.flows, id)
+	delete(s.history, id)
 	return nil
 }
 
+// DeleteAll removes the flows with the given IDs and their histories and returns the number of removed flows
+func (s *InMemoryStore) DeleteAll(ctx context.Context, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for _, id := range ids {
+		if _, exists := s.flows[id]; exists {
+			delete(s.flows, id)
+			delete(s.history, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// RenewLeases sets the lease expiry of all non-terminal flows owned by the runtime without changing their version
+func (s *InMemoryStore) RenewLeases(ctx context.Context, runtimeID string, expiry int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	renewed := 0
+	for _, flow := range s.flows {
+		if flow.RuntimeID == runtimeID && flow.State != dsdk.Completed && flow.State != dsdk.Terminated {
+			flow.LeaseExpiry = expiry
+			renewed++
+		}
+	}
+	return renewed, nil
+}
+
+// CountFlows returns the number of flows per state and transfer type, ordered by state and transfer type
+func (s *InMemoryStore) CountFlows(ctx context.Context) ([]dsdk.FlowCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type key struct {
+		state        dsdk.DataFlowState
+		transferType dsdk.TransferType
+	}
+	counts := make(map[key]int)
+	for _, flow := range s.flows {
+		counts[key{flow.State, flow.TransferType}]++
+	}
+	result := make([]dsdk.FlowCount, 0, len(counts))
+	for k, count := range counts {
+		result = append(result, dsdk.FlowCount{State: k.state, TransferType: k.transferType, Count: count})
+	}
+	slices.SortFunc(result, func(a, b dsdk.FlowCount) int {
+		return cmp.Or(
+			cmp.Compare(a.State, b.State),
+			cmp.Compare(a.TransferType.DestinationType, b.TransferType.DestinationType),
+			cmp.Compare(a.TransferType.FlowType, b.TransferType.FlowType))
+	})
+	return result, nil
+}
+
+// Query returns an iterator over copies of the flows matching the query, ordered by creation time and ID
+func (s *InMemoryStore) Query(ctx context.Context, query dsdk.DataFlowQuery) (dsdk.Iterator[*dsdk.DataFlow], error) {
+	if query.Offset < 0 || query.Limit < 0 {
+		return nil, dsdk.ErrInvalidInput
+	}
+
+	s.mu.RLock()
+	matches := make([]*dsdk.DataFlow, 0)
+	for _, flow := range s.flows {
+		if query.Matches(flow) {
+			matches = append(matches, copyFlow(flow))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].CreatedAt != matches[j].CreatedAt {
+			return matches[i].CreatedAt < matches[j].CreatedAt
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	if query.Offset >= len(matches) {
+		matches = matches[:0]
+	} else {
+		matches = matches[query.Offset:]
+	}
+	if query.Limit > 0 && query.Limit < len(matches) {
+		matches = matches[:query.Limit]
+	}
+
+	return &memoryIterator[*dsdk.DataFlow]{items: matches, index: -1}, nil
+}
+
 // memoryIterator is a simple iterator implementation for slice data
 type memoryIterator[T any] struct {
 	items []T
@@ -145,5 +283,5 @@ type InMemoryTrxContext struct {
 }
 
 func (c InMemoryTrxContext) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
-	return fn(context.TODO())
+	return fn(ctx)
 }
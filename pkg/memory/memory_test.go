@@ -175,6 +175,46 @@ func TestInMemoryStore_Save(t *testing.T) {
 		assert.NotSame(t, updatedFlow, storedFlow)
 	})
 
+	t.Run("save increments version", func(t *testing.T) {
+		err := store.Create(ctx, &dsdk.DataFlow{ID: "test-flow-2"})
+		require.NoError(t, err)
+
+		flow, err := store.FindById(ctx, "test-flow-2")
+		require.NoError(t, err)
+
+		err = store.Save(ctx, flow)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), flow.Version)
+
+		err = store.Save(ctx, flow)
+		require.NoError(t, err)
+
+		storedFlow, err := store.FindById(ctx, "test-flow-2")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), storedFlow.Version)
+	})
+
+	t.Run("save stale flow", func(t *testing.T) {
+		err := store.Create(ctx, &dsdk.DataFlow{ID: "test-flow-3"})
+		require.NoError(t, err)
+
+		first, err := store.FindById(ctx, "test-flow-3")
+		require.NoError(t, err)
+		second, err := store.FindById(ctx, "test-flow-3")
+		require.NoError(t, err)
+
+		first.State = dsdk.Terminated
+		require.NoError(t, store.Save(ctx, first))
+
+		second.State = dsdk.Started
+		err = store.Save(ctx, second)
+
+		assert.ErrorIs(t, err, dsdk.ErrConflict)
+		storedFlow, err := store.FindById(ctx, "test-flow-3")
+		require.NoError(t, err)
+		assert.Equal(t, dsdk.Terminated, storedFlow.State)
+	})
+
 	t.Run("save non-existing flow", func(t *testing.T) {
 		flow := &dsdk.DataFlow{
 			ID:        "non-existing",
@@ -245,6 +285,258 @@ func TestInMemoryStore_Delete(t *testing.T) {
 	})
 }
 
+func TestInMemoryStore_Query(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+
+	flows := []*dsdk.DataFlow{
+		{ID: "flow-1", State: dsdk.Started, ParticipantID: "p1", CounterPartyID: "c1", AgreementID: "a1", CreatedAt: 1, UpdatedAt: 100,
+			RuntimeID: "r1", LeaseExpiry: 1000,
+			TransferType: dsdk.TransferType{DestinationType: "http", FlowType: dsdk.Pull}},
+		{ID: "flow-2", State: dsdk.Suspended, ParticipantID: "p1", CounterPartyID: "c2", AgreementID: "a1", CreatedAt: 2, UpdatedAt: 200,
+			RuntimeID: "r1", LeaseExpiry: 2000,
+			TransferType: dsdk.TransferType{DestinationType: "nats", FlowType: dsdk.Push}},
+		{ID: "flow-3", State: dsdk.Terminated, ParticipantID: "p2", CounterPartyID: "c1", AgreementID: "a2", DatasetID: "d1", CreatedAt: 3, UpdatedAt: 300,
+			TransferType: dsdk.TransferType{DestinationType: "http", FlowType: dsdk.Pull}},
+	}
+	for _, flow := range flows {
+		require.NoError(t, store.Create(ctx, flow))
+	}
+
+	collect := func(t *testing.T, query dsdk.DataFlowQuery) []string {
+		iterator, err := store.Query(ctx, query)
+		require.NoError(t, err)
+		defer iterator.Close()
+		ids := make([]string, 0)
+		for iterator.Next() {
+			ids = append(ids, iterator.Get().ID)
+		}
+		require.NoError(t, iterator.Error())
+		return ids
+	}
+
+	tests := map[string]struct {
+		query    dsdk.DataFlowQuery
+		expected []string
+	}{
+		"all":             {dsdk.DataFlowQuery{}, []string{"flow-1", "flow-2", "flow-3"}},
+		"states":          {dsdk.DataFlowQuery{States: []dsdk.DataFlowState{dsdk.Started, dsdk.Suspended}}, []string{"flow-1", "flow-2"}},
+		"participant":     {dsdk.DataFlowQuery{ParticipantID: "p1"}, []string{"flow-1", "flow-2"}},
+		"counterparty":    {dsdk.DataFlowQuery{CounterPartyID: "c1"}, []string{"flow-1", "flow-3"}},
+		"agreement":       {dsdk.DataFlowQuery{AgreementID: "a2"}, []string{"flow-3"}},
+		"dataset":         {dsdk.DataFlowQuery{DatasetID: "d1"}, []string{"flow-3"}},
+		"transfer type":   {dsdk.DataFlowQuery{DestinationType: "http", FlowType: dsdk.Pull}, []string{"flow-1", "flow-3"}},
+		"updated range":   {dsdk.DataFlowQuery{UpdatedAfter: 200, UpdatedBefore: 300}, []string{"flow-2"}},
+		"runtime":         {dsdk.DataFlowQuery{RuntimeID: "r1"}, []string{"flow-1", "flow-2"}},
+		"lease expired":   {dsdk.DataFlowQuery{LeaseExpiredBefore: 1500}, []string{"flow-1"}}, // flow-3 has no lease
+		"paging":          {dsdk.DataFlowQuery{Offset: 1, Limit: 1}, []string{"flow-2"}},
+		"offset past end": {dsdk.DataFlowQuery{Offset: 5}, []string{}},
+		"no match":        {dsdk.DataFlowQuery{ParticipantID: "p1", States: []dsdk.DataFlowState{dsdk.Terminated}}, []string{}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, collect(t, test.query))
+		})
+	}
+
+	t.Run("returns copies", func(t *testing.T) {
+		iterator, err := store.Query(ctx, dsdk.DataFlowQuery{})
+		require.NoError(t, err)
+		require.True(t, iterator.Next())
+		iterator.Get().State = dsdk.Completed
+
+		stored, err := store.FindById(ctx, "flow-1")
+		require.NoError(t, err)
+		assert.Equal(t, dsdk.Started, stored.State)
+	})
+
+	t.Run("invalid paging", func(t *testing.T) {
+		_, err := store.Query(ctx, dsdk.DataFlowQuery{Limit: -1})
+		assert.ErrorIs(t, err, dsdk.ErrInvalidInput)
+	})
+}
+
+func TestInMemoryStore_UpdatedAt(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, &dsdk.DataFlow{ID: "flow-1", State: dsdk.Started}))
+	require.NoError(t, store.Create(ctx, &dsdk.DataFlow{ID: "flow-2", State: dsdk.Started}))
+
+	time.Sleep(5 * time.Millisecond)
+	updatedAfter := time.Now().UnixMilli()
+	time.Sleep(5 * time.Millisecond)
+	flow, err := store.FindById(ctx, "flow-2")
+	require.NoError(t, err)
+	require.NoError(t, flow.TransitionToCompleted())
+	require.NoError(t, store.Save(ctx, flow))
+
+	iterator, err := store.Query(ctx, dsdk.DataFlowQuery{UpdatedAfter: updatedAfter})
+	require.NoError(t, err)
+	defer iterator.Close()
+	require.True(t, iterator.Next())
+	assert.Equal(t, "flow-2", iterator.Get().ID)
+	assert.Greater(t, iterator.Get().UpdatedAt, iterator.Get().CreatedAt)
+	assert.False(t, iterator.Next())
+}
+
+func TestInMemoryStore_RetentionCountsFromLastUpdate(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	sdk, err := dsdk.NewDataPlaneSDKBuilder().
+		Store(store).
+		TransactionContext(InMemoryTrxContext{}).
+		RetentionPolicy(dsdk.RetentionPolicy{MaxAge: 50 * time.Millisecond}).
+		Build()
+	require.NoError(t, err)
+
+	// created a month ago, finished now
+	created := time.Now().Add(-30 * 24 * time.Hour).UnixMilli()
+	flow := &dsdk.DataFlow{ID: "flow-1", State: dsdk.Started, CreatedAt: created, UpdatedAt: created}
+	require.NoError(t, store.Create(ctx, flow))
+	require.NoError(t, flow.TransitionToCompleted())
+	require.NoError(t, store.Save(ctx, flow))
+
+	purged, err := sdk.PurgeFlows(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	time.Sleep(60 * time.Millisecond)
+	purged, err = sdk.PurgeFlows(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
+
+func TestInMemoryStore_Failure(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	failure := &dsdk.Failure{Code: "quota_exceeded", Message: "bucket quota exceeded", Retryable: true, Origin: dsdk.FailureOriginCounterParty}
+	require.NoError(t, store.Create(ctx, &dsdk.DataFlow{ID: "flow-1", Failure: failure}))
+
+	// modifications of the given and the returned failure must not affect the store
+	failure.Code = "modified"
+	stored, err := store.FindById(ctx, "flow-1")
+	require.NoError(t, err)
+	stored.Failure.Retryable = false
+
+	stored, err = store.FindById(ctx, "flow-1")
+	require.NoError(t, err)
+	assert.Equal(t, "quota_exceeded", stored.Failure.Code)
+	assert.True(t, stored.Failure.Retryable)
+}
+
+func TestInMemoryStore_History(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := dsdk.ContextWithMessageID(context.Background(), "msg-1")
+
+	flow := &dsdk.DataFlow{ID: "flow-1", State: dsdk.Started, RuntimeID: "r1"}
+	require.NoError(t, flow.TransitionToSuspended("maintenance"))
+	require.NoError(t, store.Create(ctx, flow))
+	assert.Empty(t, flow.PendingTransitions())
+
+	stored, err := store.FindById(ctx, "flow-1")
+	require.NoError(t, err)
+	require.NoError(t, stored.TransitionToTerminated("cancelled"))
+	require.NoError(t, store.Save(context.Background(), stored))
+
+	// a conflicting save must not record its transitions
+	stale := &dsdk.DataFlow{ID: "flow-1", State: dsdk.Started}
+	require.NoError(t, stale.TransitionToCompleted())
+	require.ErrorIs(t, store.Save(ctx, stale), dsdk.ErrConflict)
+
+	history, err := store.History(ctx, "flow-1")
+
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, dsdk.StateTransition{
+		ProcessID: "flow-1", FromState: dsdk.Started, ToState: dsdk.Suspended, Reason: "maintenance",
+		Timestamp: history[0].Timestamp, MessageID: "msg-1", RuntimeID: "r1",
+	}, history[0])
+	assert.Equal(t, dsdk.Terminated, history[1].ToState)
+	assert.Equal(t, "cancelled", history[1].Reason)
+	assert.Empty(t, history[1].MessageID)
+}
+
+func TestInMemoryStore_DeleteAll(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+
+	for _, id := range []string{"flow-1", "flow-2", "flow-3"} {
+		flow := &dsdk.DataFlow{ID: id, State: dsdk.Started}
+		require.NoError(t, flow.TransitionToCompleted())
+		require.NoError(t, store.Create(ctx, flow))
+	}
+
+	deleted, err := store.DeleteAll(ctx, []string{"flow-1", "flow-3", "unknown"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	_, err = store.FindById(ctx, "flow-1")
+	assert.ErrorIs(t, err, dsdk.ErrNotFound)
+	_, err = store.FindById(ctx, "flow-2")
+	assert.NoError(t, err)
+
+	// histories are removed with their flows
+	history, err := store.History(ctx, "flow-1")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	history, err = store.History(ctx, "flow-2")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestInMemoryStore_RenewLeases(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+
+	flows := []*dsdk.DataFlow{
+		{ID: "flow-1", State: dsdk.Started, RuntimeID: "r1", LeaseExpiry: 100},
+		{ID: "flow-2", State: dsdk.Completed, RuntimeID: "r1", LeaseExpiry: 100},
+		{ID: "flow-3", State: dsdk.Started, RuntimeID: "r2", LeaseExpiry: 100},
+	}
+	for _, flow := range flows {
+		require.NoError(t, store.Create(ctx, flow))
+	}
+
+	renewed, err := store.RenewLeases(ctx, "r1", 500)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, renewed)
+	expected := map[string]int64{"flow-1": 500, "flow-2": 100, "flow-3": 100}
+	for id, expiry := range expected {
+		stored, err := store.FindById(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, expiry, stored.LeaseExpiry, id)
+		assert.Equal(t, int64(0), stored.Version, id)
+	}
+}
+
+func TestInMemoryStore_CountFlows(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+
+	pull := dsdk.TransferType{DestinationType: "HttpData", FlowType: dsdk.Pull}
+	push := dsdk.TransferType{DestinationType: "AmazonS3", FlowType: dsdk.Push}
+	flows := []*dsdk.DataFlow{
+		{ID: "flow-1", State: dsdk.Started, TransferType: pull},
+		{ID: "flow-2", State: dsdk.Started, TransferType: pull},
+		{ID: "flow-3", State: dsdk.Started, TransferType: push},
+		{ID: "flow-4", State: dsdk.Completed, TransferType: pull},
+	}
+	for _, flow := range flows {
+		require.NoError(t, store.Create(ctx, flow))
+	}
+
+	counts, err := store.CountFlows(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, []dsdk.FlowCount{
+		{State: dsdk.Started, TransferType: push, Count: 1},
+		{State: dsdk.Started, TransferType: pull, Count: 2},
+		{State: dsdk.Completed, TransferType: pull, Count: 1},
+	}, counts)
+}
+
 func TestMemoryIterator(t *testing.T) {
 	t.Run("iterate through items", func(t *testing.T) {
 		items := []string{"item1", "item2", "item3"}
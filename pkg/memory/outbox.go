@@ -0,0 +1,107 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+)
+
+// InMemoryOutboxStore is a thread-safe in-memory implementation of OutboxStore
+type InMemoryOutboxStore struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries []*outboxEntry // ordered by ID
+}
+
+type outboxEntry struct {
+	dsdk.OutboxEntry
+	claimedUntil int64
+}
+
+// NewInMemoryOutboxStore creates a new thread-safe in-memory outbox store
+func NewInMemoryOutboxStore() *InMemoryOutboxStore {
+	return &InMemoryOutboxStore{}
+}
+
+// Append adds the entry and assigns its ID
+func (s *InMemoryOutboxStore) Append(ctx context.Context, entry *dsdk.OutboxEntry) error {
+	if entry == nil || entry.ProcessID == "" {
+		return dsdk.ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	entry.ID = s.nextID
+	s.entries = append(s.entries, &outboxEntry{OutboxEntry: *entry})
+	return nil
+}
+
+// Claim reserves the oldest due and unclaimed entry of up to limit flows
+func (s *InMemoryOutboxStore) Claim(ctx context.Context, now int64, until int64, limit int) ([]dsdk.OutboxEntry, error) {
+	if limit <= 0 {
+		return nil, dsdk.ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []dsdk.OutboxEntry
+	seen := make(map[string]bool)
+	for _, entry := range s.entries {
+		if seen[entry.ProcessID] {
+			continue // not the oldest entry of its flow
+		}
+		seen[entry.ProcessID] = true
+		if entry.NextAttemptAt > now || entry.claimedUntil >= now {
+			continue
+		}
+		entry.claimedUntil = until
+		claimed = append(claimed, entry.OutboxEntry)
+		if len(claimed) == limit {
+			break
+		}
+	}
+	return claimed, nil
+}
+
+// Delete removes the entry with the given ID
+func (s *InMemoryOutboxStore) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = slices.DeleteFunc(s.entries, func(entry *outboxEntry) bool { return entry.ID == id })
+	return nil
+}
+
+// Reschedule records a failed delivery and releases the claim of the entry
+func (s *InMemoryOutboxStore) Reschedule(ctx context.Context, id int64, nextAttemptAt int64, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.entries, func(entry *outboxEntry) bool { return entry.ID == id })
+	if index < 0 {
+		return dsdk.ErrNotFound
+	}
+	entry := s.entries[index]
+	entry.Attempts++
+	entry.NextAttemptAt = nextAttemptAt
+	entry.LastError = lastError
+	entry.claimedUntil = 0
+	return nil
+}
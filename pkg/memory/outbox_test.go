@@ -0,0 +1,81 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryOutboxStore_ClaimsOldestEntryPerFlow(t *testing.T) {
+	store := NewInMemoryOutboxStore()
+	ctx := context.Background()
+
+	for _, processID := range []string{"flow1", "flow1", "flow2"} {
+		entry := &dsdk.OutboxEntry{ProcessID: processID, Flow: dsdk.DataFlow{ID: processID}, NextAttemptAt: 10}
+		require.NoError(t, store.Append(ctx, entry))
+		assert.NotZero(t, entry.ID)
+	}
+
+	claimed, err := store.Claim(ctx, 5, 100, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "entries are not due yet")
+
+	claimed, err = store.Claim(ctx, 10, 100, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, []int64{1, 3}, []int64{claimed[0].ID, claimed[1].ID})
+
+	claimed, err = store.Claim(ctx, 20, 100, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "claimed entries are reserved")
+
+	require.NoError(t, store.Delete(ctx, 1))
+	claimed, err = store.Claim(ctx, 20, 100, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, int64(2), claimed[0].ID)
+
+	claimed, err = store.Claim(ctx, 101, 200, 10)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 2, "expired claims are released")
+}
+
+func TestInMemoryOutboxStore_Reschedule(t *testing.T) {
+	store := NewInMemoryOutboxStore()
+	ctx := context.Background()
+	first := &dsdk.OutboxEntry{ProcessID: "flow1", NextAttemptAt: 10}
+	require.NoError(t, store.Append(ctx, first))
+	require.NoError(t, store.Append(ctx, &dsdk.OutboxEntry{ProcessID: "flow1", NextAttemptAt: 10}))
+
+	_, err := store.Claim(ctx, 10, 100, 10)
+	require.NoError(t, err)
+	require.NoError(t, store.Reschedule(ctx, first.ID, 50, "unavailable"))
+
+	claimed, err := store.Claim(ctx, 20, 100, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "later entries wait for the rescheduled entry")
+
+	claimed, err = store.Claim(ctx, 50, 100, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, first.ID, claimed[0].ID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	assert.Equal(t, "unavailable", claimed[0].LastError)
+
+	assert.ErrorIs(t, store.Reschedule(ctx, 42, 0, ""), dsdk.ErrNotFound)
+}
@@ -10,7 +10,8 @@ CREATE TABLE IF NOT EXISTS data_flows
     consumer               BOOLEAN          NOT NULL,           -- DataFlow.Consumer
     agreement_id           TEXT             NOT NULL,           -- DataFlow.AgreementID
     dataset_id             TEXT             NOT NULL,           -- DataFlow.DatasetID
-    runtime_id             TEXT             NOT NULL,           -- DataFlow.RuntimeID
+    runtime_id             TEXT             NOT NULL,           -- DataFlow.RuntimeID (owning runtime)
+    lease_expiry_ms        BIGINT           NOT NULL DEFAULT 0, -- DataFlow.LeaseExpiry (epoch millis)
     participant_id         TEXT             NOT NULL,           -- DataFlow.ParticipantID
     dataspace_context      TEXT             NOT NULL,           -- DataFlow.DataspaceContext
     counterparty_id        TEXT             NOT NULL,           -- DataFlow.CounterPartyID
@@ -25,19 +26,72 @@ CREATE TABLE IF NOT EXISTS data_flows
     state                  INTEGER          NOT NULL DEFAULT 0, -- DataFlow.State (enum int)
     state_count            INTEGER          NOT NULL DEFAULT 0, -- DataFlow.StateCount
     state_timestamp_ms     BIGINT           NOT NULL,           -- DataFlow.StateTimestamp (epoch millis)
+    retry_count            INTEGER          NOT NULL DEFAULT 0, -- DataFlow.RetryCount (watchdog retries in the current state)
 
     error_detail           VARCHAR,                             -- DataFlow.ErrorDetail
+    failure                JSONB,                               -- DataFlow.Failure {code, message, retryable, origin, timestamp}
 
     created_at_ms          BIGINT           NOT NULL,           -- DataFlow.CreatedAt (epoch millis)
     updated_at_ms          BIGINT           NOT NULL            -- DataFlow.UpdatedAt (epoch millis)
 );
 
+-- Columns added after the initial release, for databases created from an earlier schema
+ALTER TABLE data_flows ADD COLUMN IF NOT EXISTS lease_expiry_ms BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE data_flows ADD COLUMN IF NOT EXISTS retry_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE data_flows ADD COLUMN IF NOT EXISTS failure JSONB;
+
 -- Helpful indexes
 CREATE INDEX IF NOT EXISTS idx_data_flows_state ON data_flows (state);
 CREATE INDEX IF NOT EXISTS idx_data_flows_updated_at ON data_flows (updated_at_ms);
 CREATE INDEX IF NOT EXISTS idx_data_flows_agreement ON data_flows (agreement_id);
 CREATE INDEX IF NOT EXISTS idx_data_flows_dataset ON data_flows (dataset_id);
 CREATE INDEX IF NOT EXISTS idx_data_flows_participant ON data_flows (participant_id);
+CREATE INDEX IF NOT EXISTS idx_data_flows_counterparty ON data_flows (counterparty_id);
+CREATE INDEX IF NOT EXISTS idx_data_flows_created_at ON data_flows (created_at_ms, id);
+CREATE INDEX IF NOT EXISTS idx_data_flows_runtime ON data_flows (runtime_id);
+CREATE INDEX IF NOT EXISTS idx_data_flows_lease_expiry ON data_flows (lease_expiry_ms);
+
+-- Append-only history of data flow state transitions, deleted with the flow (DataplaneStore.History)
+CREATE TABLE IF NOT EXISTS data_flow_transitions
+(
+    id           BIGSERIAL PRIMARY KEY,             -- insertion order
+    process_id   TEXT    NOT NULL,                  -- StateTransition.ProcessID
+    from_state   INTEGER NOT NULL,                  -- StateTransition.FromState (enum int)
+    to_state     INTEGER NOT NULL,                  -- StateTransition.ToState (enum int)
+    reason       TEXT    NOT NULL DEFAULT '',       -- StateTransition.Reason
+    message_id   TEXT    NOT NULL DEFAULT '',       -- StateTransition.MessageID
+    runtime_id   TEXT    NOT NULL DEFAULT '',       -- StateTransition.RuntimeID
+    timestamp_ms BIGINT  NOT NULL                   -- StateTransition.Timestamp (epoch millis)
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_flow_transitions_process ON data_flow_transitions (process_id, id);
+
+-- Control plane notifications written with the state change and delivered by the outbox relay (OutboxStore)
+CREATE TABLE IF NOT EXISTS data_flow_outbox
+(
+    id               BIGSERIAL PRIMARY KEY,              -- OutboxEntry.ID (delivery order)
+    process_id       TEXT    NOT NULL,                   -- OutboxEntry.ProcessID
+    flow             JSONB   NOT NULL,                   -- OutboxEntry.Flow (snapshot)
+    data_address     JSONB,                              -- OutboxEntry.DataAddress
+    attempts         INTEGER NOT NULL DEFAULT 0,         -- OutboxEntry.Attempts
+    next_attempt_ms  BIGINT  NOT NULL,                   -- OutboxEntry.NextAttemptAt (epoch millis)
+    last_error       TEXT    NOT NULL DEFAULT '',        -- OutboxEntry.LastError
+    claimed_until_ms BIGINT  NOT NULL DEFAULT 0,         -- end of the relay claim (epoch millis)
+    created_at_ms    BIGINT  NOT NULL                    -- OutboxEntry.CreatedAt (epoch millis)
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_flow_outbox_process ON data_flow_outbox (process_id, id);
+
+-- Responses of processed signaling messages, used to answer replays (IdempotencyStore)
+CREATE TABLE IF NOT EXISTS idempotency_records
+(
+    message_id    TEXT PRIMARY KEY NOT NULL, -- IdempotencyRecord.MessageID
+    process_id    TEXT             NOT NULL, -- IdempotencyRecord.ProcessID
+    response      JSONB            NOT NULL, -- IdempotencyRecord.Response
+    created_at_ms BIGINT           NOT NULL  -- IdempotencyRecord.CreatedAt (epoch millis)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_records_created_at ON idempotency_records (created_at_ms);
 
 -- Optional JSONB GIN indexes (uncomment if you need property-level queries)
 -- CREATE INDEX IF NOT EXISTS idx_data_flows_transfer_type_gin ON data_flows USING GIN (transfer_type);
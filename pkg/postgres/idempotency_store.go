@@ -0,0 +1,70 @@
+//go:build postgres
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+)
+
+// IdempotencyStore is a Postgres implementation of dsdk.IdempotencyStore backed by the idempotency_records table.
+type IdempotencyStore struct {
+	db *sql.DB
+}
+
+func NewIdempotencyStore(db *sql.DB) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+func (s IdempotencyStore) FindByMessageId(ctx context.Context, messageID string) (*dsdk.IdempotencyRecord, error) {
+	query := `SELECT message_id, process_id, response, created_at_ms FROM idempotency_records WHERE message_id = $1`
+	var record dsdk.IdempotencyRecord
+	var response []byte
+	err := executorFor(ctx, s.db).QueryRowContext(ctx, query, messageID).
+		Scan(&record.MessageID, &record.ProcessID, &response, &record.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, dsdk.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(response, &record.Response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &record, nil
+}
+
+func (s IdempotencyStore) Save(ctx context.Context, record *dsdk.IdempotencyRecord) error {
+	if record == nil || record.MessageID == "" {
+		return dsdk.ErrInvalidInput
+	}
+	query := `INSERT INTO idempotency_records (message_id, process_id, response, created_at_ms)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (message_id) DO UPDATE SET
+			process_id = EXCLUDED.process_id,
+			response = EXCLUDED.response,
+			created_at_ms = EXCLUDED.created_at_ms`
+	_, err := executorFor(ctx, s.db).ExecContext(ctx, query,
+		record.MessageID,
+		record.ProcessID,
+		toJson(record.Response),
+		record.CreatedAt)
+	return err
+}
+
+func (s IdempotencyStore) DeleteExpired(ctx context.Context, before int64) (int, error) {
+	res, err := executorFor(ctx, s.db).ExecContext(ctx, `DELETE FROM idempotency_records WHERE created_at_ms < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
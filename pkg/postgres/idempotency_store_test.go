@@ -0,0 +1,54 @@
+//go:build postgres
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IdempotencyStore_SaveAndFind(t *testing.T) {
+	idempotencyStore := NewIdempotencyStore(testDB)
+	messageID := uuid.NewString()
+
+	_, err := idempotencyStore.FindByMessageId(ctx, messageID)
+	assert.ErrorIs(t, err, dsdk.ErrNotFound)
+
+	record := &dsdk.IdempotencyRecord{
+		MessageID: messageID,
+		ProcessID: "process1",
+		Response:  dsdk.DataFlowResponseMessage{State: dsdk.Started, DataplaneID: "dp1"},
+		CreatedAt: 100,
+	}
+	require.NoError(t, idempotencyStore.Save(ctx, record))
+
+	found, err := idempotencyStore.FindByMessageId(ctx, messageID)
+	require.NoError(t, err)
+	assert.Equal(t, record, found)
+
+	record.CreatedAt = 200
+	require.NoError(t, idempotencyStore.Save(ctx, record))
+	found, err = idempotencyStore.FindByMessageId(ctx, messageID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), found.CreatedAt)
+}
+
+func Test_IdempotencyStore_DeleteExpired(t *testing.T) {
+	idempotencyStore := NewIdempotencyStore(testDB)
+	oldID, newID := uuid.NewString(), uuid.NewString()
+	require.NoError(t, idempotencyStore.Save(ctx, &dsdk.IdempotencyRecord{MessageID: oldID, ProcessID: "p", CreatedAt: 1}))
+	require.NoError(t, idempotencyStore.Save(ctx, &dsdk.IdempotencyRecord{MessageID: newID, ProcessID: "p", CreatedAt: 1 << 50}))
+
+	deleted, err := idempotencyStore.DeleteExpired(ctx, 2)
+
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, 1)
+	_, err = idempotencyStore.FindByMessageId(ctx, oldID)
+	assert.ErrorIs(t, err, dsdk.ErrNotFound)
+	_, err = idempotencyStore.FindByMessageId(ctx, newID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,116 @@
+//go:build postgres
+
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+)
+
+// OutboxStore is a Postgres implementation of dsdk.OutboxStore backed by the data_flow_outbox table. Entries appended
+// inside a DBTransactionContext are committed or rolled back together with the flow.
+type OutboxStore struct {
+	db *sql.DB
+}
+
+func NewOutboxStore(db *sql.DB) *OutboxStore {
+	return &OutboxStore{db: db}
+}
+
+func (s OutboxStore) Append(ctx context.Context, entry *dsdk.OutboxEntry) error {
+	if entry == nil || entry.ProcessID == "" {
+		return dsdk.ErrInvalidInput
+	}
+	var dataAddress *string
+	if entry.DataAddress != nil {
+		dataAddress = toJson(entry.DataAddress)
+	}
+	query := `INSERT INTO data_flow_outbox (process_id, flow, data_address, attempts, next_attempt_ms, last_error, created_at_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+	return executorFor(ctx, s.db).QueryRowContext(ctx, query,
+		entry.ProcessID,
+		toJson(entry.Flow),
+		dataAddress,
+		entry.Attempts,
+		entry.NextAttemptAt,
+		entry.LastError,
+		entry.CreatedAt).Scan(&entry.ID)
+}
+
+// Claim reserves the oldest entry of up to limit flows. Rows locked by a concurrent claim are skipped.
+func (s OutboxStore) Claim(ctx context.Context, now int64, until int64, limit int) ([]dsdk.OutboxEntry, error) {
+	if limit <= 0 {
+		return nil, dsdk.ErrInvalidInput
+	}
+	query := `UPDATE data_flow_outbox SET claimed_until_ms = $2
+		WHERE id IN (
+			SELECT o.id FROM data_flow_outbox o
+			WHERE o.next_attempt_ms <= $1 AND o.claimed_until_ms < $1
+				AND NOT EXISTS (SELECT 1 FROM data_flow_outbox p WHERE p.process_id = o.process_id AND p.id < o.id)
+			ORDER BY o.id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id, process_id, flow, data_address, attempts, next_attempt_ms, last_error, created_at_ms`
+	rows, err := executorFor(ctx, s.db).QueryContext(ctx, query, now, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []dsdk.OutboxEntry
+	for rows.Next() {
+		var entry dsdk.OutboxEntry
+		var flow []byte
+		var dataAddress []byte
+		if err := rows.Scan(&entry.ID, &entry.ProcessID, &flow, &dataAddress, &entry.Attempts, &entry.NextAttemptAt,
+			&entry.LastError, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(flow, &entry.Flow); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal flow of outbox entry %d: %w", entry.ID, err)
+		}
+		if dataAddress != nil {
+			entry.DataAddress = &dsdk.DataAddress{}
+			if err := json.Unmarshal(dataAddress, entry.DataAddress); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal data address of outbox entry %d: %w", entry.ID, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING does not preserve the order of the subquery
+	slices.SortFunc(entries, func(a, b dsdk.OutboxEntry) int { return cmp.Compare(a.ID, b.ID) })
+	return entries, nil
+}
+
+func (s OutboxStore) Delete(ctx context.Context, id int64) error {
+	_, err := executorFor(ctx, s.db).ExecContext(ctx, `DELETE FROM data_flow_outbox WHERE id = $1`, id)
+	return err
+}
+
+func (s OutboxStore) Reschedule(ctx context.Context, id int64, nextAttemptAt int64, lastError string) error {
+	query := `UPDATE data_flow_outbox
+		SET attempts = attempts + 1, next_attempt_ms = $2, last_error = $3, claimed_until_ms = 0
+		WHERE id = $1`
+	res, err := executorFor(ctx, s.db).ExecContext(ctx, query, id, nextAttemptAt, lastError)
+	if err != nil {
+		return err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return dsdk.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+//go:build postgres
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OutboxStore_ClaimAndDelete(t *testing.T) {
+	outboxStore := NewOutboxStore(testDB)
+	processID := uuid.NewString()
+	first := &dsdk.OutboxEntry{
+		ProcessID:     processID,
+		Flow:          dsdk.DataFlow{ID: processID, State: dsdk.Started},
+		DataAddress:   &dsdk.DataAddress{Properties: map[string]any{"endpoint": "https://example.com"}},
+		NextAttemptAt: 10,
+		CreatedAt:     10,
+	}
+	second := &dsdk.OutboxEntry{ProcessID: processID, Flow: dsdk.DataFlow{ID: processID, State: dsdk.Completed}, NextAttemptAt: 10}
+	require.NoError(t, outboxStore.Append(ctx, first))
+	require.NoError(t, outboxStore.Append(ctx, second))
+	assert.Greater(t, second.ID, first.ID)
+
+	claimed, err := outboxStore.Claim(ctx, 10, 100, 1000)
+	require.NoError(t, err)
+	entry := findEntry(claimed, processID)
+	require.NotNil(t, entry)
+	assert.Equal(t, first.ID, entry.ID)
+	assert.Equal(t, dsdk.Started, entry.Flow.State)
+	assert.Equal(t, first.DataAddress, entry.DataAddress)
+
+	claimed, err = outboxStore.Claim(ctx, 20, 100, 1000)
+	require.NoError(t, err)
+	assert.Nil(t, findEntry(claimed, processID), "the flow has a claimed entry")
+
+	require.NoError(t, outboxStore.Delete(ctx, first.ID))
+	claimed, err = outboxStore.Claim(ctx, 20, 100, 1000)
+	require.NoError(t, err)
+	entry = findEntry(claimed, processID)
+	require.NotNil(t, entry)
+	assert.Equal(t, second.ID, entry.ID)
+	assert.Nil(t, entry.DataAddress)
+}
+
+func Test_OutboxStore_Reschedule(t *testing.T) {
+	outboxStore := NewOutboxStore(testDB)
+	processID := uuid.NewString()
+	entry := &dsdk.OutboxEntry{ProcessID: processID, Flow: dsdk.DataFlow{ID: processID}, NextAttemptAt: 10}
+	require.NoError(t, outboxStore.Append(ctx, entry))
+
+	_, err := outboxStore.Claim(ctx, 10, 100, 1000)
+	require.NoError(t, err)
+	require.NoError(t, outboxStore.Reschedule(ctx, entry.ID, 50, "unavailable"))
+
+	claimed, err := outboxStore.Claim(ctx, 20, 100, 1000)
+	require.NoError(t, err)
+	assert.Nil(t, findEntry(claimed, processID))
+
+	claimed, err = outboxStore.Claim(ctx, 50, 100, 1000)
+	require.NoError(t, err)
+	found := findEntry(claimed, processID)
+	require.NotNil(t, found)
+	assert.Equal(t, 1, found.Attempts)
+	assert.Equal(t, "unavailable", found.LastError)
+
+	assert.ErrorIs(t, outboxStore.Reschedule(ctx, -1, 0, ""), dsdk.ErrNotFound)
+}
+
+func Test_OutboxStore_RolledBackWithFlow(t *testing.T) {
+	outboxStore := NewOutboxStore(testDB)
+	processID := uuid.NewString()
+	trxContext := NewDBTransactionContext(testDB)
+
+	err := trxContext.Execute(ctx, func(ctx context.Context) error {
+		if err := store.Create(ctx, &dsdk.DataFlow{ID: processID}); err != nil {
+			return err
+		}
+		if err := outboxStore.Append(ctx, &dsdk.OutboxEntry{ProcessID: processID, NextAttemptAt: 10}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+
+	claimed, err := outboxStore.Claim(ctx, 10, 100, 1000)
+	require.NoError(t, err)
+	assert.Nil(t, findEntry(claimed, processID))
+}
+
+func findEntry(entries []dsdk.OutboxEntry, processID string) *dsdk.OutboxEntry {
+	for _, entry := range entries {
+		if entry.ProcessID == processID {
+			return &entry
+		}
+	}
+	return nil
+}
@@ -7,13 +7,22 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
 	"github.com/metaform/dataplane-sdk-go/pkg/dsdk"
 )
 
+// dbExecutor is implemented by both *sql.DB and *sql.Tx.
+type dbExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type PostgresStore struct {
 	db *sql.DB
 }
@@ -22,20 +31,125 @@ func NewStore(db *sql.DB) *PostgresStore {
 	return &PostgresStore{db: db}
 }
 
+// dataFlowColumns lists the columns read by scanDataFlow, in scan order.
+const dataFlowColumns = `id, version, consumer, agreement_id, dataset_id, runtime_id, lease_expiry_ms, participant_id,
+	dataspace_context, counterparty_id, callback_address, transfer_type_dest, transfer_type_flowtype, source_data_address,
+	dest_data_address, state, state_count, state_timestamp_ms, retry_count, error_detail, failure, created_at_ms, updated_at_ms`
+
+// FindById returns the flow with the given id. When called inside a DBTransactionContext, the flow row is locked until
+// the transaction completes so that concurrent messages for the same process are serialized. The lock is also taken
+// if the flow does not exist yet, which serializes concurrent creation. Reads outside a transaction, such as
+// DataPlaneSDK.Status and History, take no lock. A second transaction that loads the flow while the first one is open
+// waits for it, so code running in the first transaction must not wait for the second, see dsdk.DataFlowProcessor.
 func (p PostgresStore) FindById(ctx context.Context, id string) (*dsdk.DataFlow, error) {
-	query := `SELECT * FROM data_flows WHERE id = $1`
+	query := `SELECT ` + dataFlowColumns + ` FROM data_flows WHERE id = $1`
+	executor := p.executor(ctx)
+	if _, ok := transactionFrom(ctx); ok {
+		if _, err := executor.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, id); err != nil {
+			return nil, err
+		}
+		query += ` FOR UPDATE`
+	}
+
+	df, err := scanDataFlow(executor.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, dsdk.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return df, nil
+}
+
+// Query returns a cursor-backed iterator over the flows matching the query, ordered by creation time and ID.
+func (p PostgresStore) Query(ctx context.Context, query dsdk.DataFlowQuery) (dsdk.Iterator[*dsdk.DataFlow], error) {
+	if query.Offset < 0 || query.Limit < 0 {
+		return nil, dsdk.ErrInvalidInput
+	}
+
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
 
+	if len(query.States) > 0 {
+		states := make([]int64, len(query.States))
+		for i, state := range query.States {
+			states[i] = int64(state)
+		}
+		where("state = ANY($%d)", pq.Array(states))
+	}
+	if query.ParticipantID != "" {
+		where("participant_id = $%d", query.ParticipantID)
+	}
+	if query.CounterPartyID != "" {
+		where("counterparty_id = $%d", query.CounterPartyID)
+	}
+	if query.AgreementID != "" {
+		where("agreement_id = $%d", query.AgreementID)
+	}
+	if query.DatasetID != "" {
+		where("dataset_id = $%d", query.DatasetID)
+	}
+	if query.DestinationType != "" {
+		where("transfer_type_dest = $%d", query.DestinationType)
+	}
+	if query.FlowType != "" {
+		where("transfer_type_flowtype = $%d", string(query.FlowType))
+	}
+	if query.UpdatedAfter != 0 {
+		where("updated_at_ms >= $%d", query.UpdatedAfter)
+	}
+	if query.UpdatedBefore != 0 {
+		where("updated_at_ms < $%d", query.UpdatedBefore)
+	}
+	if query.RuntimeID != "" {
+		where("runtime_id = $%d", query.RuntimeID)
+	}
+	if query.LeaseExpiredBefore != 0 {
+		where("lease_expiry_ms > 0 AND lease_expiry_ms < $%d", query.LeaseExpiredBefore)
+	}
+
+	statement := `SELECT ` + dataFlowColumns + ` FROM data_flows`
+	if len(conditions) > 0 {
+		statement += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	statement += ` ORDER BY created_at_ms, id`
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		statement += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if query.Offset > 0 {
+		args = append(args, query.Offset)
+		statement += fmt.Sprintf(` OFFSET $%d`, len(args))
+	}
+
+	rows, err := p.executor(ctx).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &rowsIterator{rows: rows}, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDataFlow(row rowScanner) (*dsdk.DataFlow, error) {
 	var df dsdk.DataFlow
 	var callbackAddressJson string
-	var sourceDataAddressJson, destDataAddressJson *string
+	var sourceDataAddressJson, destDataAddressJson, failureJson *string
 
-	err := p.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&df.ID,
 		&df.Version,
 		&df.Consumer,
 		&df.AgreementID,
 		&df.DatasetID,
 		&df.RuntimeID,
+		&df.LeaseExpiry,
 		&df.ParticipantID,
 		&df.DataspaceContext,
 		&df.CounterPartyID,
@@ -47,14 +161,12 @@ func (p PostgresStore) FindById(ctx context.Context, id string) (*dsdk.DataFlow,
 		&df.State,
 		&df.StateCount,
 		&df.StateTimestamp,
+		&df.RetryCount,
 		&df.ErrorDetail,
+		&failureJson,
 		&df.CreatedAt,
 		&df.UpdatedAt,
 	)
-
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, dsdk.ErrNotFound
-	}
 	if err != nil {
 		return nil, err
 	}
@@ -75,9 +187,46 @@ func (p PostgresStore) FindById(ctx context.Context, id string) (*dsdk.DataFlow,
 		}
 	}
 
+	if failureJson != nil {
+		if err := json.Unmarshal([]byte(*failureJson), &df.Failure); err != nil {
+			return nil, err
+		}
+	}
+
 	return &df, nil
 }
 
+// rowsIterator iterates over query results, decoding one flow per call to Next
+type rowsIterator struct {
+	rows    *sql.Rows
+	current *dsdk.DataFlow
+	err     error
+}
+
+func (it *rowsIterator) Next() bool {
+	if it.err != nil || !it.rows.Next() {
+		it.current = nil
+		return false
+	}
+	it.current, it.err = scanDataFlow(it.rows)
+	return it.err == nil
+}
+
+func (it *rowsIterator) Get() *dsdk.DataFlow {
+	return it.current
+}
+
+func (it *rowsIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.rows.Err()
+}
+
+func (it *rowsIterator) Close() error {
+	return it.rows.Close()
+}
+
 func (p PostgresStore) Create(ctx context.Context, flow *dsdk.DataFlow) error {
 	if flow.ID == "" {
 		return dsdk.ErrInvalidInput
@@ -101,14 +250,24 @@ func (p PostgresStore) Create(ctx context.Context, flow *dsdk.DataFlow) error {
 		    state_timestamp_ms,
 		    error_detail,
 		    created_at_ms,
-		    updated_at_ms
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
+		    updated_at_ms,
+		    version,
+		    lease_expiry_ms,
+		    state_count,
+		    retry_count,
+		    failure
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)`
 
 	cba, err := flow.CallbackAddress.MarshalJSON()
 	if err != nil {
 		return err
 	}
-	_, err = p.db.ExecContext(ctx, query,
+	now := time.Now().UnixMilli()
+	stateTimestamp := flow.StateTimestamp
+	if stateTimestamp == 0 {
+		stateTimestamp = now
+	}
+	_, err = p.executor(ctx).ExecContext(ctx, query,
 		flow.ID,
 		flow.Consumer,
 		flow.AgreementID,
@@ -123,10 +282,15 @@ func (p PostgresStore) Create(ctx context.Context, flow *dsdk.DataFlow) error {
 		toJson(flow.SourceDataAddress),
 		toJson(flow.DestinationDataAddress),
 		flow.State,
-		time.Now().UnixMilli(),
+		stateTimestamp,
 		flow.ErrorDetail,
-		time.Now().UnixMilli(),
-		time.Now().UnixMilli(),
+		now,
+		now,
+		flow.Version,
+		flow.LeaseExpiry,
+		flow.StateCount,
+		flow.RetryCount,
+		failureToJson(flow.Failure),
 	)
 
 	if err != nil {
@@ -136,16 +300,17 @@ func (p PostgresStore) Create(ctx context.Context, flow *dsdk.DataFlow) error {
 		return err
 	}
 
-	return nil
+	return p.appendTransitions(ctx, flow)
 }
 
+// Save updates an existing flow and returns dsdk.ErrNotFound if the flow does not exist. Updates use optimistic
+// locking: if the stored version differs from the version of the given flow, dsdk.ErrConflict is returned. On success, the version is incremented and
+// UpdatedAt is set to the time of the update.
 func (p PostgresStore) Save(ctx context.Context, flow *dsdk.DataFlow) error {
 	if flow.ID == "" {
 		return dsdk.ErrInvalidInput
 	}
-	if exists(p.db, ctx, flow.ID) {
-		// update
-		query := `
+	query := `
 		UPDATE data_flows
 		SET 
 		    consumer = $1,
@@ -163,38 +328,38 @@ func (p PostgresStore) Save(ctx context.Context, flow *dsdk.DataFlow) error {
 		    state = $13,
 			state_timestamp_ms = $14,
 		    error_detail = $15,
-		    updated_at_ms = $16
-		WHERE id = $17`
-
-		_, err := p.db.ExecContext(ctx, query,
-			flow.Consumer,
-			flow.AgreementID,
-			flow.DatasetID,
-			flow.RuntimeID,
-			flow.ParticipantID,
-			flow.DataspaceContext,
-			flow.CounterPartyID,
-			toJson(flow.CallbackAddress),
-			flow.TransferType.DestinationType,
-			flow.TransferType.FlowType,
-			toJson(flow.SourceDataAddress),
-			toJson(flow.DestinationDataAddress),
-			flow.State,
-			flow.StateTimestamp,
-			flow.ErrorDetail,
-			time.Now().UnixMilli(),
-			flow.ID)
-		if err != nil {
-			return err
-		}
-		return nil
-	}
-	return p.Create(ctx, flow)
-}
+		    updated_at_ms = $16,
+		    lease_expiry_ms = $19,
+		    state_count = $20,
+		    retry_count = $21,
+		    failure = $22,
+		    version = version + 1
+		WHERE id = $17 AND version = $18`
 
-func (p PostgresStore) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM data_flows WHERE id = $1`
-	res, err := p.db.ExecContext(ctx, query, id)
+	now := time.Now().UnixMilli()
+	res, err := p.executor(ctx).ExecContext(ctx, query,
+		flow.Consumer,
+		flow.AgreementID,
+		flow.DatasetID,
+		flow.RuntimeID,
+		flow.ParticipantID,
+		flow.DataspaceContext,
+		flow.CounterPartyID,
+		toJson(flow.CallbackAddress),
+		flow.TransferType.DestinationType,
+		flow.TransferType.FlowType,
+		toJson(flow.SourceDataAddress),
+		toJson(flow.DestinationDataAddress),
+		flow.State,
+		flow.StateTimestamp,
+		flow.ErrorDetail,
+		now,
+		flow.ID,
+		flow.Version,
+		flow.LeaseExpiry,
+		flow.StateCount,
+		flow.RetryCount,
+		failureToJson(flow.Failure))
 	if err != nil {
 		return err
 	}
@@ -202,12 +367,143 @@ func (p PostgresStore) Delete(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
+	if rowsAffected == 1 {
+		flow.Version++
+		flow.UpdatedAt = now
+		return p.appendTransitions(ctx, flow)
+	}
+	found, err := exists(p.executor(ctx), ctx, flow.ID)
+	if err != nil {
+		return fmt.Errorf("checking data flow %s: %w", flow.ID, err)
+	}
+	if !found {
 		return dsdk.ErrNotFound
 	}
+	return fmt.Errorf("%w: data flow %s was modified concurrently (version %d)", dsdk.ErrConflict, flow.ID, flow.Version)
+}
+
+// RenewLeases sets the lease expiry of all non-terminal flows owned by the runtime. The version is not incremented so
+// that renewals do not conflict with concurrent updates of the flows.
+func (p PostgresStore) RenewLeases(ctx context.Context, runtimeID string, expiry int64) (int, error) {
+	query := `UPDATE data_flows SET lease_expiry_ms = $1 WHERE runtime_id = $2 AND state <> ALL($3)`
+	res, err := p.executor(ctx).ExecContext(ctx, query, expiry, runtimeID, pq.Array([]int64{int64(dsdk.Completed), int64(dsdk.Terminated)}))
+	if err != nil {
+		return 0, err
+	}
+	renewed, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(renewed), nil
+}
+
+// CountFlows returns the number of flows per state and transfer type, ordered by state and transfer type.
+func (p PostgresStore) CountFlows(ctx context.Context) ([]dsdk.FlowCount, error) {
+	query := `SELECT state, transfer_type_dest, transfer_type_flowtype, COUNT(*) FROM data_flows
+		GROUP BY state, transfer_type_dest, transfer_type_flowtype
+		ORDER BY state, transfer_type_dest, transfer_type_flowtype`
+	rows, err := p.executor(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []dsdk.FlowCount
+	for rows.Next() {
+		var count dsdk.FlowCount
+		if err := rows.Scan(&count.State, &count.TransferType.DestinationType, &count.TransferType.FlowType, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// Delete removes the flow and its history.
+func (p PostgresStore) Delete(ctx context.Context, id string) error {
+	deleted, err := p.deleteFlows(ctx, []string{id})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return dsdk.ErrNotFound
+	}
+	return nil
+}
+
+// appendTransitions inserts the pending transitions of the flow into the history table. Called from Create and Save, it
+// runs in the transaction of the context, if any.
+func (p PostgresStore) appendTransitions(ctx context.Context, flow *dsdk.DataFlow) error {
+	query := `INSERT INTO data_flow_transitions (process_id, from_state, to_state, reason, message_id, runtime_id, timestamp_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	executor := p.executor(ctx)
+	for _, transition := range dsdk.TransitionsToPersist(ctx, flow) {
+		_, err := executor.ExecContext(ctx, query,
+			transition.ProcessID,
+			transition.FromState,
+			transition.ToState,
+			transition.Reason,
+			transition.MessageID,
+			transition.RuntimeID,
+			transition.Timestamp)
+		if err != nil {
+			return fmt.Errorf("recording transition of data flow %s: %w", flow.ID, err)
+		}
+	}
+	flow.ClearTransitions()
 	return nil
 }
 
+// History returns the recorded transitions of the flow in insertion order.
+func (p PostgresStore) History(ctx context.Context, processID string) ([]dsdk.StateTransition, error) {
+	query := `SELECT process_id, from_state, to_state, reason, message_id, runtime_id, timestamp_ms
+		FROM data_flow_transitions WHERE process_id = $1 ORDER BY id`
+	rows, err := p.executor(ctx).QueryContext(ctx, query, processID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := make([]dsdk.StateTransition, 0)
+	for rows.Next() {
+		var transition dsdk.StateTransition
+		err := rows.Scan(
+			&transition.ProcessID,
+			&transition.FromState,
+			&transition.ToState,
+			&transition.Reason,
+			&transition.MessageID,
+			&transition.RuntimeID,
+			&transition.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, transition)
+	}
+	return transitions, rows.Err()
+}
+
+// DeleteAll removes the flows with the given IDs and their histories in a single statement.
+func (p PostgresStore) DeleteAll(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return p.deleteFlows(ctx, ids)
+}
+
+// deleteFlows removes the flows and their transitions in one statement, so that no history is left behind even outside
+// a transaction.
+func (p PostgresStore) deleteFlows(ctx context.Context, ids []string) (int, error) {
+	query := `WITH deleted AS (DELETE FROM data_flows WHERE id = ANY($1) RETURNING id),
+		history AS (DELETE FROM data_flow_transitions WHERE process_id IN (SELECT id FROM deleted))
+		SELECT count(*) FROM deleted`
+	var deleted int
+	if err := p.executor(ctx).QueryRowContext(ctx, query, pq.Array(ids)).Scan(&deleted); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
 func toJson(v any) *string {
 	j, err := json.Marshal(v)
 	if err != nil {
@@ -218,14 +514,26 @@ func toJson(v any) *string {
 	return &s
 }
 
-func exists(db *sql.DB, ctx context.Context, id string) bool {
+// failureToJson serializes the failure of a flow. Flows without a failure are stored with a NULL failure.
+func failureToJson(failure *dsdk.Failure) *string {
+	if failure == nil {
+		return nil
+	}
+	return toJson(failure)
+}
+
+// executor returns the transaction opened by DBTransactionContext if the context carries one, otherwise the database.
+func (p PostgresStore) executor(ctx context.Context) dbExecutor {
+	return executorFor(ctx, p.db)
+}
+
+func exists(db dbExecutor, ctx context.Context, id string) (bool, error) {
 	query := `SELECT COUNT(*) FROM data_flows WHERE id = $1`
 	var count int
-	err := db.QueryRowContext(ctx, query, id).Scan(&count)
-	if err != nil {
-		return false
+	if err := db.QueryRowContext(ctx, query, id).Scan(&count); err != nil {
+		return false, err
 	}
-	return count > 0
+	return count > 0, nil
 }
 
 // isUniqueViolation detects Postgres unique violations by checking for SQLState "23505".
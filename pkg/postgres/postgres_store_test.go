@@ -6,7 +6,9 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"errors"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -109,6 +111,119 @@ func Test_Delete_Existing(t *testing.T) {
 	assert.NoError(t, err2)
 }
 
+func Test_Failure(t *testing.T) {
+	id := uuid.New().String()
+	failure := &dsdk.Failure{Code: "quota_exceeded", Message: "bucket quota exceeded", Retryable: true,
+		Origin: dsdk.FailureOriginCounterParty, Timestamp: 1700000000000}
+	assert.NoError(t, store.Create(ctx, &dsdk.DataFlow{ID: id, State: dsdk.Started}))
+
+	found, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.Nil(t, found.Failure)
+
+	found.Failure = failure
+	assert.NoError(t, store.Save(ctx, found))
+	found, err = store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, failure, found.Failure)
+
+	found.Failure = nil
+	assert.NoError(t, store.Save(ctx, found))
+	found, err = store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.Nil(t, found.Failure)
+}
+
+func Test_History(t *testing.T) {
+	id := uuid.New().String()
+	messageCtx := dsdk.ContextWithMessageID(ctx, "msg-1")
+
+	flow := &dsdk.DataFlow{ID: id, State: dsdk.Started, RuntimeID: "r1"}
+	assert.NoError(t, flow.TransitionToSuspended("maintenance"))
+	assert.NoError(t, store.Create(messageCtx, flow))
+	assert.Empty(t, flow.PendingTransitions())
+
+	found, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.NoError(t, found.TransitionToTerminated("cancelled"))
+	assert.NoError(t, store.Save(ctx, found))
+
+	history, err := store.History(ctx, id)
+
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, dsdk.Suspended, history[0].ToState)
+	assert.Equal(t, "maintenance", history[0].Reason)
+	assert.Equal(t, "msg-1", history[0].MessageID)
+	assert.Equal(t, "r1", history[0].RuntimeID)
+	assert.Equal(t, dsdk.Suspended, history[1].FromState)
+	assert.Equal(t, dsdk.Terminated, history[1].ToState)
+}
+
+func Test_History_DeletedWithFlow(t *testing.T) {
+	ids := []string{uuid.New().String(), uuid.New().String(), uuid.New().String()}
+	for _, id := range ids {
+		flow := &dsdk.DataFlow{ID: id, State: dsdk.Started}
+		assert.NoError(t, flow.TransitionToCompleted())
+		assert.NoError(t, store.Create(ctx, flow))
+	}
+
+	assert.NoError(t, store.Delete(ctx, ids[0]))
+	deleted, err := store.DeleteAll(ctx, []string{ids[1]})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	for _, id := range ids[:2] {
+		history, err := store.History(ctx, id)
+		assert.NoError(t, err)
+		assert.Empty(t, history)
+	}
+	history, err := store.History(ctx, ids[2])
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func Test_History_RolledBackWithFlow(t *testing.T) {
+	id := uuid.New().String()
+	trxContext := NewDBTransactionContext(testDB)
+
+	err := trxContext.Execute(ctx, func(ctx context.Context) error {
+		flow := &dsdk.DataFlow{ID: id, State: dsdk.Started}
+		if err := flow.TransitionToCompleted(); err != nil {
+			return err
+		}
+		if err := store.Create(ctx, flow); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+
+	history, err := store.History(ctx, id)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func Test_DeleteAll(t *testing.T) {
+	ids := []string{uuid.New().String(), uuid.New().String(), uuid.New().String()}
+	for _, id := range ids {
+		assert.NoError(t, store.Create(ctx, &dsdk.DataFlow{ID: id}))
+	}
+
+	deleted, err := store.DeleteAll(ctx, []string{ids[0], ids[2], uuid.New().String()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	_, err = store.FindById(ctx, ids[0])
+	assert.ErrorIs(t, err, dsdk.ErrNotFound)
+	_, err = store.FindById(ctx, ids[1])
+	assert.NoError(t, err)
+
+	deleted, err = store.DeleteAll(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+}
+
 func Test_Save_Exists_ShouldUpdate(t *testing.T) {
 	id := uuid.New().String()
 	err := store.Create(ctx, &dsdk.DataFlow{
@@ -133,19 +248,60 @@ func Test_Save_Exists_ShouldUpdate(t *testing.T) {
 	assert.Equal(t, "new-agreement-id", agr)
 }
 
-func Test_Save_NotExists_ShouldCreateNew(t *testing.T) {
+func Test_Save_IncrementsVersion(t *testing.T) {
+	id := uuid.New().String()
+	err := store.Create(ctx, &dsdk.DataFlow{
+		ID: id,
+	})
+	assert.NoError(t, err)
+
+	flow, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Save(ctx, flow))
+	assert.Equal(t, int64(1), flow.Version)
+
+	found, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), found.Version)
+}
+
+func Test_Save_StaleVersion_ShouldConflict(t *testing.T) {
+	id := uuid.New().String()
+	err := store.Create(ctx, &dsdk.DataFlow{
+		ID: id,
+	})
+	assert.NoError(t, err)
+
+	first, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+	second, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+
+	first.State = dsdk.Terminated
+	assert.NoError(t, store.Save(ctx, first))
+
+	second.State = dsdk.Started
+	err = store.Save(ctx, second)
+	assert.ErrorIs(t, err, dsdk.ErrConflict)
+
+	found, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, dsdk.Terminated, found.State)
+}
+
+func Test_Save_NotExists_ShouldFail(t *testing.T) {
 	id := uuid.New().String()
 	err2 := store.Save(ctx, &dsdk.DataFlow{
 		ID:          id,
 		AgreementID: "agreement-id",
 	})
-	assert.NoError(t, err2)
+	assert.ErrorIs(t, err2, dsdk.ErrNotFound)
 
-	// verify the number of rows
+	// verify that no row was created
 	var num int
 	e := testDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM data_flows WHERE id = $1", id).Scan(&num)
 	assert.NoError(t, e)
-	assert.Equal(t, 1, num)
+	assert.Equal(t, 0, num)
 }
 
 func Test_Save_InvalidInput(t *testing.T) {
@@ -157,7 +313,7 @@ func Test_Save_InvalidInput(t *testing.T) {
 
 func Test_FindById(t *testing.T) {
 	id := uuid.New().String()
-	err := store.Save(ctx, &dsdk.DataFlow{
+	err := store.Create(ctx, &dsdk.DataFlow{
 		ID: id,
 	})
 	assert.NoError(t, err)
@@ -169,7 +325,7 @@ func Test_FindById(t *testing.T) {
 
 func Test_FindById_WithDataAddress(t *testing.T) {
 	id := uuid.New().String()
-	err := store.Save(ctx, &dsdk.DataFlow{
+	err := store.Create(ctx, &dsdk.DataFlow{
 		ID: id,
 		SourceDataAddress: dsdk.DataAddress{
 			Properties: map[string]any{
@@ -185,7 +341,180 @@ func Test_FindById_WithDataAddress(t *testing.T) {
 	assert.Equal(t, "bar", found.SourceDataAddress.Properties["foo"])
 }
 
+func Test_Save_PersistsStateTracking(t *testing.T) {
+	id := uuid.New().String()
+	flow := &dsdk.DataFlow{ID: id, State: dsdk.Starting, StateCount: 2, StateTimestamp: 1000, RetryCount: 1}
+	assert.NoError(t, store.Create(ctx, flow))
+
+	found, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), found.StateCount)
+	assert.Equal(t, int64(1000), found.StateTimestamp)
+	assert.Equal(t, uint(1), found.RetryCount)
+
+	found.RetryCount = 2
+	found.StateTimestamp = 2000
+	assert.NoError(t, store.Save(ctx, found))
+
+	found, err = store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), found.RetryCount)
+	assert.Equal(t, int64(2000), found.StateTimestamp)
+}
+
 func Test_FindById_NotExists(t *testing.T) {
 	_, err := store.FindById(ctx, "non-existing")
 	assert.ErrorIs(t, err, dsdk.ErrNotFound)
 }
+
+func Test_Create_InTransaction_RolledBack(t *testing.T) {
+	id := uuid.New().String()
+	trxContext := NewDBTransactionContext(testDB)
+
+	err := trxContext.Execute(ctx, func(ctx context.Context) error {
+		if err := store.Create(ctx, &dsdk.DataFlow{ID: id}); err != nil {
+			return err
+		}
+		// visible inside the transaction
+		if _, err := store.FindById(ctx, id); err != nil {
+			return err
+		}
+		return errors.New("processor failed")
+	})
+	assert.ErrorContains(t, err, "processor failed")
+
+	_, err = store.FindById(ctx, id)
+	assert.ErrorIs(t, err, dsdk.ErrNotFound)
+}
+
+func Test_FindById_InTransaction_SerializesUpdates(t *testing.T) {
+	id := uuid.New().String()
+	assert.NoError(t, store.Create(ctx, &dsdk.DataFlow{ID: id}))
+	trxContext := NewDBTransactionContext(testDB)
+
+	const workers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- trxContext.Execute(ctx, func(ctx context.Context) error {
+				flow, err := store.FindById(ctx, id)
+				if err != nil {
+					return err
+				}
+				flow.StateCount++
+				return store.Save(ctx, flow)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		// without row locking, concurrent saves would fail with a version conflict
+		assert.NoError(t, err)
+	}
+	found, err := store.FindById(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(workers), found.Version)
+}
+
+func Test_Query(t *testing.T) {
+	agreementID := uuid.New().String()
+	participantID := uuid.New().String()
+	ids := make([]string, 3)
+	states := []dsdk.DataFlowState{dsdk.Started, dsdk.Suspended, dsdk.Terminated}
+	for i := range ids {
+		ids[i] = uuid.New().String()
+		err := store.Create(ctx, &dsdk.DataFlow{
+			ID:            ids[i],
+			AgreementID:   agreementID,
+			ParticipantID: participantID,
+			State:         states[i],
+			TransferType:  dsdk.TransferType{DestinationType: "http", FlowType: dsdk.Pull},
+		})
+		assert.NoError(t, err)
+		time.Sleep(2 * time.Millisecond) // distinct creation timestamps
+	}
+
+	collect := func(query dsdk.DataFlowQuery) []string {
+		iterator, err := store.Query(ctx, query)
+		assert.NoError(t, err)
+		defer iterator.Close()
+		result := make([]string, 0)
+		for iterator.Next() {
+			result = append(result, iterator.Get().ID)
+		}
+		assert.NoError(t, iterator.Error())
+		return result
+	}
+
+	assert.Equal(t, ids, collect(dsdk.DataFlowQuery{AgreementID: agreementID}))
+	assert.Equal(t, ids[:2], collect(dsdk.DataFlowQuery{
+		AgreementID: agreementID,
+		States:      []dsdk.DataFlowState{dsdk.Started, dsdk.Suspended},
+	}))
+	assert.Equal(t, ids[1:2], collect(dsdk.DataFlowQuery{ParticipantID: participantID, Offset: 1, Limit: 1}))
+	assert.Equal(t, ids, collect(dsdk.DataFlowQuery{AgreementID: agreementID, DestinationType: "http", FlowType: dsdk.Pull}))
+	assert.Empty(t, collect(dsdk.DataFlowQuery{AgreementID: agreementID, UpdatedBefore: 1}))
+}
+
+func Test_RenewLeases(t *testing.T) {
+	runtimeID := uuid.New().String()
+	started := &dsdk.DataFlow{ID: uuid.New().String(), RuntimeID: runtimeID, State: dsdk.Started, LeaseExpiry: 1}
+	terminated := &dsdk.DataFlow{ID: uuid.New().String(), RuntimeID: runtimeID, State: dsdk.Terminated, LeaseExpiry: 1}
+	other := &dsdk.DataFlow{ID: uuid.New().String(), RuntimeID: uuid.New().String(), State: dsdk.Started, LeaseExpiry: 1}
+	for _, flow := range []*dsdk.DataFlow{started, terminated, other} {
+		assert.NoError(t, store.Create(ctx, flow))
+	}
+
+	renewed, err := store.RenewLeases(ctx, runtimeID, 5000)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, renewed)
+	found, err := store.FindById(ctx, started.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5000), found.LeaseExpiry)
+	assert.Equal(t, started.Version, found.Version, "renewal must not change the version")
+	found, err = store.FindById(ctx, terminated.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), found.LeaseExpiry)
+
+	iterator, err := store.Query(ctx, dsdk.DataFlowQuery{RuntimeID: runtimeID, LeaseExpiredBefore: 5000})
+	assert.NoError(t, err)
+	defer iterator.Close()
+	assert.True(t, iterator.Next())
+	assert.Equal(t, terminated.ID, iterator.Get().ID)
+	assert.False(t, iterator.Next())
+}
+
+func Test_CountFlows(t *testing.T) {
+	destinationType := uuid.New().String()
+	pull := dsdk.TransferType{DestinationType: destinationType, FlowType: dsdk.Pull}
+	push := dsdk.TransferType{DestinationType: destinationType, FlowType: dsdk.Push}
+	for _, flow := range []*dsdk.DataFlow{
+		{ID: uuid.New().String(), State: dsdk.Started, TransferType: pull},
+		{ID: uuid.New().String(), State: dsdk.Started, TransferType: pull},
+		{ID: uuid.New().String(), State: dsdk.Started, TransferType: push},
+		{ID: uuid.New().String(), State: dsdk.Completed, TransferType: pull},
+	} {
+		assert.NoError(t, store.Create(ctx, flow))
+	}
+
+	counts, err := store.CountFlows(ctx)
+
+	assert.NoError(t, err)
+	var own []dsdk.FlowCount
+	for _, count := range counts {
+		if count.TransferType.DestinationType == destinationType {
+			own = append(own, count)
+		}
+	}
+	assert.Equal(t, []dsdk.FlowCount{
+		{State: dsdk.Started, TransferType: pull, Count: 2},
+		{State: dsdk.Started, TransferType: push, Count: 1},
+		{State: dsdk.Completed, TransferType: pull, Count: 1},
+	}, own)
+}
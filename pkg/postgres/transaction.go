@@ -53,3 +53,17 @@ func (trxContext *DBTransactionContext) Execute(ctx context.Context, operation f
 
 	return nil
 }
+
+// transactionFrom returns the transaction stored in the context by DBTransactionContext, if any.
+func transactionFrom(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(DBTransactionKey).(*sql.Tx)
+	return tx, ok && tx != nil
+}
+
+// executorFor returns the transaction stored in the context, or the database if no transaction is active.
+func executorFor(ctx context.Context, db *sql.DB) dbExecutor {
+	if tx, ok := transactionFrom(ctx); ok {
+		return tx
+	}
+	return db
+}